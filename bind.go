@@ -1,16 +1,16 @@
 package binding
 
 import (
-	"errors"
 	"fmt"
 	"mime/multipart"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/tidwall/gjson"
 )
 
-func Bind(r Request, recvPtr interface{}) error {
+func (b *Binder) Bind(r Request, recvPtr interface{}) error {
 	recvType := reflect.TypeOf(recvPtr)
 	if recvType.Kind() != reflect.Ptr {
 		return fmt.Errorf("A pointer is required but [%v] provided", recvType)
@@ -20,9 +20,9 @@ func Bind(r Request, recvPtr interface{}) error {
 		return fmt.Errorf("A struct is required but [%v] provided", recvType)
 	}
 
-	structMeta := ParseStruct(reflect.ValueOf(recvPtr).Elem().Interface())
+	structMeta := b.getStructMeta(recvType)
 
-	err := BindWithStructMeta(r, recvPtr, structMeta)
+	err := b.BindWithStructMeta(r, recvPtr, structMeta)
 	if err != nil {
 		return err
 	}
@@ -30,7 +30,7 @@ func Bind(r Request, recvPtr interface{}) error {
 	return nil
 }
 
-func BindWithStructMeta(r Request, recvPtr interface{}, structMeta *StructMetadata) error {
+func (b *Binder) BindWithStructMeta(r Request, recvPtr interface{}, structMeta *StructMetadata) error {
 	recvType := reflect.TypeOf(recvPtr)
 	if recvType.Kind() != reflect.Ptr {
 		return fmt.Errorf("A pointer is required but [%v] provided", recvType)
@@ -40,28 +40,30 @@ func BindWithStructMeta(r Request, recvPtr interface{}, structMeta *StructMetada
 		return nil
 	}
 
+	_, err := b.bind(r, recvPtr, structMeta)
+	return err
+}
+
+// bind 绑定请求，返回绑定后的 StructMetadata，用于获取字段的状态
+func (b *Binder) bind(r Request, recvPtr interface{}, structMeta *StructMetadata) (*StructMetadata, error) {
 	req, err := newRequest(r)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	req.doc = b.decodeBody(req.contentType, req.body)
 
 	sm := structMeta.clone()
-	bindStruct(req, reflect.ValueOf(recvPtr), sm)
-
-	err = checkFields(sm)
-	if err != nil {
-		return err
-	}
+	b.bindStruct(req, reflect.ValueOf(recvPtr), sm)
 
-	return nil
+	return sm, b.checkFields(req, sm)
 }
 
-func bindStruct(r *request, recv reflect.Value, structMeta *StructMetadata) (set bool) {
+func (b *Binder) bindStruct(r *request, recv reflect.Value, structMeta *StructMetadata) (set bool) {
 	set = false
 	for i := 0; i < structMeta.FieldNum; i++ {
 		fieldMeta := (structMeta.FieldList)[i]
 		if !fieldMeta.isIgnored && fieldMeta.isExported {
-			resolveField(r, fieldMeta)
+			b.resolveField(r, fieldMeta)
 			if !fieldMeta.isUnset {
 				set = true
 			}
@@ -71,19 +73,25 @@ func bindStruct(r *request, recv reflect.Value, structMeta *StructMetadata) (set
 	return
 }
 
-func resolveField(r *request, fieldMeta *fieldMetadata) {
+func (b *Binder) resolveField(r *request, fieldMeta *fieldMetadata) {
 	var value reflect.Value
 	fieldMeta.value = &value
 
-	value = getFieldValue(r, fieldMeta)
-	if fieldMeta.hasValue {
+	// 没有注册 convertor 的 map 按 key 绑定
+	if fieldMeta.isMap && b.getFieldConvertor(fieldMeta, fieldMeta.elemType, false) == nil {
+		value = b.bindMap(r, fieldMeta)
+		return
+	}
+
+	value = b.getFieldValue(r, fieldMeta)
+	if fieldMeta.hasValue || fieldMeta.isNull {
 		return
 	}
 
 	fieldMeta.isUnset = false
 
 	if fieldMeta.isFile {
-		files, ok := r.GetFormFile(fieldMeta.fieldName)
+		files, ok := r.GetFormFile(fieldMeta.nameOf(form))
 		if !ok {
 			fieldMeta.isUnset = true
 			return
@@ -104,18 +112,14 @@ func resolveField(r *request, fieldMeta *fieldMetadata) {
 		fieldMeta.hasValue = true
 	} else if fieldMeta.isStruct {
 		value = reflect.New(fieldMeta.structMeta.StructType)
-		fieldMeta.isUnset = !bindStruct(r, value, fieldMeta.structMeta)
+		fieldMeta.isUnset = !b.bindStruct(r, value, fieldMeta.structMeta)
 		fieldMeta.hasValue = !fieldMeta.isUnset
 		value = value.Elem()
 	} else if fieldMeta.isSlice && fieldMeta.sliceMeta.isStruct {
 		sliceMeta := fieldMeta.sliceMeta
-		body := r.GetBody()
-		validJson := gjson.ValidBytes(body)
-		arrayResult := gjson.GetBytes(body, sliceMeta.fieldJsonName)
+		length, ok := b.structSliceLen(r, fieldMeta)
 
-		if validJson && arrayResult.Exists() {
-			array := arrayResult.Array()
-			length := len(array)
+		if ok {
 			value = reflect.MakeSlice(sliceMeta.sliceType, length, length)
 			sliceMeta.structData = make([]*StructMetadata, length)
 			for j := range sliceMeta.structData {
@@ -123,7 +127,7 @@ func resolveField(r *request, fieldMeta *fieldMetadata) {
 				sliceMeta.structData[j].attachLayerNum(j)
 
 				receiver := reflect.New(sliceMeta.elemType)
-				bindStruct(r, receiver, sliceMeta.structData[j])
+				b.bindStruct(r, receiver, sliceMeta.structData[j])
 
 				if !sliceMeta.isPtr {
 					receiver = receiver.Elem()
@@ -143,29 +147,54 @@ func resolveField(r *request, fieldMeta *fieldMetadata) {
 	}
 
 	if !fieldMeta.isUnset {
-		fieldMeta.hasConversionError = false
+		fieldMeta.conversionErr = nil
 		fieldMeta.hasValue = true
 	}
 }
 
-func getFieldValue(r *request, fieldMeta *fieldMetadata) (value reflect.Value) {
+func (b *Binder) getFieldValue(r *request, fieldMeta *fieldMetadata) (value reflect.Value) {
+	elemType := fieldMeta.elemType
+	if fieldMeta.isSlice {
+		elemType = fieldMeta.sliceMeta.elemType
+	}
+	// 合并多个来源时统一使用 string 数据
+	fieldMeta.rawJSON = b.useJSONUnmarshaler(elemType) && !fieldMeta.isMerge
+
 	// 获取原始的 string 数据
-	originValues, ok := getValue(r, fieldMeta)
+	originValues, ok := b.getValue(r, fieldMeta)
 	if !ok {
 		fieldMeta.isUnset = true
 		return
 	}
+	if fieldMeta.isNull {
+		// json 中的 null 视为传入了值，字段为零值，指针、slice、map 为 nil
+		fieldMeta.isUnset = false
+		return
+	}
+
+	// 没有 convertor 的结构体以及结构体 slice 由 resolveField 逐个字段绑定，不再解析整段 json
+	isStruct := fieldMeta.isStruct || fieldMeta.isSlice && fieldMeta.sliceMeta.isStruct
+	if isStruct && fieldMeta.valueSource == bindJson && b.getFieldConvertor(fieldMeta, elemType, false) == nil {
+		return
+	}
+
+	// 解码后的 body 中类型匹配的值直接赋给字段，不再经过 string
+	if v, ok := b.convertBodyValue(fieldMeta, elemType); ok {
+		value = v
+		fieldMeta.hasValue = true
+		return
+	}
 
 	var after []string
 	var processed bool
 	for _, name := range fieldMeta.preprocessor {
-		processor, ok := getPreprocessor(name)
+		processor, ok := b.getPreprocessor(name)
 		if ok {
 			processed = true
 			for _, v := range originValues {
 				res, err := processor(v)
 				if err != nil {
-					fieldMeta.errs = append(fieldMeta.errs, err)
+					fieldMeta.errs = append(fieldMeta.errs, FieldPreprocessError.with(fieldMeta.valuePath(), fieldMeta.valueSource, v, err))
 				}
 				after = append(after, res...)
 			}
@@ -176,8 +205,8 @@ func getFieldValue(r *request, fieldMeta *fieldMetadata) (value reflect.Value) {
 	}
 
 	// 根据注册的 convertor 转化为对应的类型
-	elemType := fieldMeta.elemType
 	length := len(originValues)
+	rawJSON := fieldMeta.rawJSON && fieldMeta.valueSource == bindJson
 
 	if fieldMeta.isSlice {
 		sliceMeta := fieldMeta.sliceMeta
@@ -186,23 +215,36 @@ func getFieldValue(r *request, fieldMeta *fieldMetadata) (value reflect.Value) {
 			if gjson.Valid(originValue) {
 				array := gjson.Parse(originValue).Array()
 				for _, result := range array {
-					tempValues = append(tempValues, result.String())
+					if rawJSON {
+						tempValues = append(tempValues, result.Raw)
+					} else {
+						tempValues = append(tempValues, result.String())
+					}
 				}
 			} else {
 				tempValues = append(tempValues, originValue)
 			}
 		}
+		if fieldMeta.isUnique {
+			tempValues = uniqueValues(tempValues)
+		}
 		length = len(tempValues)
 		originValues = tempValues
 
 		value = reflect.MakeSlice(sliceMeta.sliceType, length, length)
-		elemType = sliceMeta.elemType
 	}
 
-	convertor := getConvertor(elemType)
+	convertor := b.getFieldConvertor(fieldMeta, elemType, rawJSON)
+	ctx := &ConvertContext{
+		Field:  fieldMeta.valuePath(),
+		Type:   elemType,
+		Tag:    fieldMeta.tagInfo,
+		Source: fieldMeta.valueSource,
+	}
 	if convertor == nil {
 		if len(originValues) > 0 {
-			fieldMeta.hasConversionError = true
+			err := fmt.Errorf("no convertor for type %v", elemType)
+			fieldMeta.conversionErr = FieldConversionError.with(ctx.Field, fieldMeta.valueSource, originValues[0], err)
 			return
 		} else {
 			return
@@ -211,11 +253,19 @@ func getFieldValue(r *request, fieldMeta *fieldMetadata) (value reflect.Value) {
 
 	for i, originValue := range originValues {
 		var convertedValue interface{}
-		convertedValue, err := convertor(originValue)
+		convertedValue, err := convertor.Convert(ctx, originValue)
 		v := reflect.ValueOf(convertedValue)
-		if err != nil {
-			fieldMeta.hasConversionError = true
+		if v.IsValid() && !v.Type().ConvertibleTo(elemType) {
+			err = fmt.Errorf("convertor returned %v instead of %v", v.Type(), elemType)
+			v = reflect.Value{}
+		}
+		if err != nil && fieldMeta.conversionErr == nil {
+			fieldMeta.conversionErr = FieldConversionError.with(ctx.Field, fieldMeta.valueSource, originValue, err)
 		}
+		if !v.IsValid() {
+			v = reflect.Zero(elemType)
+		}
+		v = v.Convert(elemType)
 
 		// 如果不是 slice，直接返回第一个
 		if !fieldMeta.isSlice {
@@ -225,160 +275,218 @@ func getFieldValue(r *request, fieldMeta *fieldMetadata) (value reflect.Value) {
 		}
 
 		if fieldMeta.sliceMeta.isPtr {
-			ptr := reflect.New(v.Type())
+			ptr := reflect.New(elemType)
 			ptr.Elem().Set(v)
 			v = ptr
 		}
 
 		value.Index(i).Set(v)
 	}
-	fieldMeta.hasValue = true
+	// 不是 slice 时到这里说明 preprocessor 没有返回任何值
+	fieldMeta.hasValue = fieldMeta.isSlice
 	return
 }
 
-func checkFields(structMeta *StructMetadata) error {
-	notFoundError := make([]string, 0)
-	conversionError := make([]string, 0)
-	errs := make([]string, 0)
+func (b *Binder) checkFields(r *request, structMeta *StructMetadata) error {
+	errs := collectErrors(structMeta, nil)
+	errs = validateFields(structMeta, errs)
+	if b.strict != nil {
+		errs = b.strict.checkUnknown(r, structMeta, b.noCase, errs)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+func collectErrors(structMeta *StructMetadata, errs BindErrors) BindErrors {
 	for _, field := range structMeta.FieldList {
 		if field.isUnset && field.isRequired {
-			notFoundError = append(notFoundError, field.fieldJsonName)
+			errs = append(errs, FieldNotFound.with(field.fieldJsonName, sourceName(field.source), "", nil))
 		}
-		if field.hasConversionError {
-			conversionError = append(conversionError, field.fieldJsonName)
+		if field.isNull && field.isNotNull {
+			errs = append(errs, FieldNullError.with(field.fieldJsonName, field.valueSource, "null", nil))
 		}
-		for _, err := range field.errs {
-			errs = append(errs, err.Error())
+		if field.conversionErr != nil {
+			errs = append(errs, field.conversionErr)
 		}
+		errs = append(errs, field.errs...)
 
 		if field.isFile {
 			continue
 		}
 		if field.isStruct {
-			notFound, conversion, es := checkFields2(field.structMeta)
-			notFoundError = append(notFoundError, notFound...)
-			conversionError = append(conversionError, conversion...)
-			errs = append(errs, es...)
+			errs = collectErrors(field.structMeta, errs)
 		} else if field.isSlice {
 			for _, sd := range field.sliceMeta.structData {
-				notFound, conversion, es := checkFields2(sd)
-				notFoundError = append(notFoundError, notFound...)
-				conversionError = append(conversionError, conversion...)
-				errs = append(errs, es...)
+				errs = collectErrors(sd, errs)
+			}
+		} else if field.isMap {
+			for _, sd := range field.mapMeta.structData {
+				errs = collectErrors(sd, errs)
 			}
 		}
 	}
+	return errs
+}
 
-	sb := strings.Builder{}
-	if len(notFoundError) != 0 {
-		sb.WriteString(fmt.Sprintf("parameter required but not found: [%v]", strings.Join(notFoundError, ", ")))
-	}
-	if len(conversionError) != 0 {
-		if sb.Len() != 0 {
-			sb.WriteString("; ")
+// getValue 按来源的顺序获取原始的 string 数据，都没有时使用 default 值
+func (b *Binder) getValue(r *request, fieldMeta *fieldMetadata) (originValue []string, present bool) {
+	merge := fieldMeta.isMerge && fieldMeta.isSlice
+	var sources []string
+	for _, source := range b.fieldSources(fieldMeta) {
+		values, ok := b.lookupSource(r, fieldMeta, source)
+		if !ok {
+			continue
 		}
-
-		sb.WriteString(fmt.Sprintf("parameter type cannot be converted from string: [%v]", strings.Join(conversionError, ", ")))
+		if !merge {
+			fieldMeta.valueSource = sourceNames[source]
+			return values, true
+		}
+		originValue = append(originValue, values...)
+		sources = append(sources, sourceNames[source])
 	}
-	for _, err := range errs {
-		sb.WriteString(err)
+	if len(sources) != 0 {
+		// 只有 json 中的值为 null 时才视为 null
+		fieldMeta.isNull = fieldMeta.isNull && len(sources) == 1
+		fieldMeta.valueSource = strings.Join(sources, split)
+		return originValue, true
 	}
 
-	if sb.Len() == 0 {
-		return nil
+	if fieldMeta.hasDefault {
+		fieldMeta.valueSource = tagDefault
+		present = true
+		originValue = []string{fieldMeta.defaultVal}
 	}
 
-	return errors.New(sb.String())
+	return
 }
 
-func checkFields2(structMeta *StructMetadata) ([]string, []string, []string) {
-	notFoundError := make([]string, 0)
-	conversionError := make([]string, 0)
-	errs := make([]string, 0)
-	for _, field := range structMeta.FieldList {
-		if field.isUnset && field.isRequired {
-			notFoundError = append(notFoundError, field.fieldJsonName)
-		}
-		if field.hasConversionError {
-			conversionError = append(conversionError, field.fieldJsonName)
+// structSliceLen 返回 json 数组或者重复的 xml 元素的个数
+func (b *Binder) structSliceLen(r *request, fieldMeta *fieldMetadata) (int, bool) {
+	if hasTag(fieldMeta.source, json) {
+		if elems, ok := r.GetDocumentArray(b.jsonPath(r, fieldMeta, fieldMeta.sliceMeta.fieldJsonName)); ok {
+			fieldMeta.valueSource = bindJson
+			return len(elems), true
 		}
-		for _, err := range field.errs {
-			errs = append(errs, err.Error())
+	}
+	if hasTag(fieldMeta.source, xml) && r.xml != nil {
+		if _, elems := r.xml.lookup(fieldMeta.sliceMeta.fieldXmlName); len(elems) != 0 {
+			fieldMeta.valueSource = bindXml
+			return len(elems), true
 		}
+	}
+	return 0, false
+}
 
-		if field.isFile {
-			continue
-		}
-		if field.isStruct {
-			notFound, conversion, es := checkFields2(field.structMeta)
-			notFoundError = append(notFoundError, notFound...)
-			conversionError = append(conversionError, conversion...)
-			errs = append(errs, es...)
-		} else if field.isSlice {
-			for _, sd := range field.sliceMeta.structData {
-				notFound, conversion, es := checkFields2(sd)
-				notFoundError = append(notFoundError, notFound...)
-				conversionError = append(conversionError, conversion...)
-				errs = append(errs, es...)
-			}
+// fieldSources 按获取值的顺序返回字段的来源
+func (b *Binder) fieldSources(fieldMeta *fieldMetadata) []int {
+	order := b.sourceOrder
+	// tag 中没有列出来源时，如只有 json:"-" 的字段，使用默认的顺序
+	if fieldMeta.source != auto && len(fieldMeta.sourceList) != 0 && (fieldMeta.isOrdered || b.tagOrder) {
+		order = fieldMeta.sourceList
+	}
+
+	sources := make([]int, 0, len(order))
+	for _, source := range order {
+		if hasTag(fieldMeta.source, source) {
+			sources = append(sources, source)
 		}
 	}
-	return notFoundError, conversionError, errs
+	return sources
 }
 
-func getValue(r *request, fieldMeta *fieldMetadata) (originValue []string, present bool) {
-	if hasTag(fieldMeta.source, header) {
-		key := fieldMeta.fieldName
+// lookupSource 从一个来源获取原始的 string 数据
+func (b *Binder) lookupSource(r *request, fieldMeta *fieldMetadata, source int) (originValue []string, present bool) {
+	noCase := fieldMeta.isNoCase || b.noCase
+	switch source {
+	case header:
+		key := fieldMeta.nameOf(header)
 		key = strings.ToLower(key)
 		key = strings.ReplaceAll(key, "-", " ")
 		key = strings.Title(key)
 		key = strings.ReplaceAll(key, " ", "-")
 
-		originValue, present = r.GetHeader(key)
-		if present {
-			return
+		return r.GetHeader(key)
+	case cookie:
+		return r.GetCookies(fieldMeta.nameOf(cookie))
+	case query:
+		key := fieldMeta.nameOf(query)
+		if noCase {
+			key = foldKey(fieldMeta, source, key, r.FoldQueryKey(key))
 		}
-	}
-
-	if hasTag(fieldMeta.source, query) {
-		originValue, present = r.GetQuery(fieldMeta.fieldName)
-		if present {
-			return
+		return r.GetQuery(key)
+	case path:
+		value, ok := r.getPathParam(fieldMeta.nameOf(path))
+		if ok {
+			return []string{value}, true
 		}
-	}
-
-	if hasTag(fieldMeta.source, path) {
-		var value string
-		value, present = r.getPathParam(fieldMeta.fieldName)
-		if present {
-			originValue = []string{value}
-			return
+	case form:
+		key := fieldMeta.nameOf(form)
+		if noCase {
+			key = foldKey(fieldMeta, source, key, r.FoldPostFormKey(key))
+		}
+		return r.GetPostForm(key)
+	case json:
+		v, ok := r.GetDocument(b.jsonPath(r, fieldMeta, fieldMeta.fieldJsonName))
+		if !ok {
+			return nil, false
+		}
+		if v.Kind == NullValue {
+			fieldMeta.isNull = true
+			return nil, true
 		}
+		fieldMeta.bodyValue = v.Typed
+		return []string{v.text(fieldMeta.rawJSON)}, true
+	case xml:
+		return r.GetXML(fieldMeta.fieldXmlName)
 	}
+	return nil, false
+}
 
-	if hasTag(fieldMeta.source, form) {
-		originValue, present = r.GetPostForm(fieldMeta.fieldName)
-		if present {
-			return
-		}
+// jsonPath 不区分大小写时把 path 的第一段换成 json 中的写法
+func (b *Binder) jsonPath(r *request, fieldMeta *fieldMetadata, path string) string {
+	if !fieldMeta.isNoCase && !b.noCase {
+		return path
 	}
+	top, rest := path, ""
+	if i := strings.Index(path, "."); i >= 0 {
+		top, rest = path[:i], path[i:]
+	}
+	return foldKey(fieldMeta, json, top, r.FoldJsonKey(top)) + rest
+}
 
-	if hasTag(fieldMeta.source, json) {
-		body := r.GetBody()
-		if gjson.ValidBytes(body) {
-			v := gjson.GetBytes(body, fieldMeta.fieldJsonName)
-			present = v.Exists()
-			if present {
-				originValue = []string{v.String()}
-				return
-			}
+// foldKey 从只有大小写不同的 keys 中选出要使用的 key，优先完全相同的，
+// 有多个都不完全相同时记录错误并使用原来的 key
+func foldKey(fieldMeta *fieldMetadata, source int, key string, keys []string) string {
+	if len(keys) == 1 {
+		return keys[0]
+	}
+	for _, k := range keys {
+		if k == key {
+			return k
 		}
 	}
-
-	if fieldMeta.hasDefault {
-		present = true
-		originValue = []string{fieldMeta.defaultVal}
+	if len(keys) > 1 {
+		sorted := append([]string(nil), keys...)
+		sort.Strings(sorted)
+		err := fmt.Errorf("found %s", strings.Join(sorted, ", "))
+		fieldMeta.errs = append(fieldMeta.errs, FieldAmbiguousError.with(fieldMeta.fieldJsonName, sourceNames[source], "", err))
 	}
+	return key
+}
 
-	return
+// uniqueValues 去掉重复的值，保留第一次出现的顺序
+func uniqueValues(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	unique := values[:0]
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		unique = append(unique, v)
+	}
+	return unique
 }
@@ -1,10 +1,17 @@
 package binding
 
 import (
+	"bytes"
 	js "encoding/json"
+	"errors"
+	"math/big"
+	"net"
 	"net/http"
 	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +19,12 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func init() {
+	RegisterPreprocessor("__testErr", func(origin string) ([]string, error) {
+		return []string{}, errors.New("__testErr")
+	})
+}
+
 func TestQuerySplit(t *testing.T) {
 	type Recv struct {
 		X *struct {
@@ -290,6 +303,70 @@ func TestHeaderNum(t *testing.T) {
 	assert.Equal(t, (*int64)(nil), recv.Z)
 }
 
+func TestCookieString(t *testing.T) {
+	type Recv struct {
+		X *struct {
+			A []string  `bind:"a,cookie"`
+			B string    `bind:"b,cookie"`
+			C *[]string `bind:"c,cookie,req"`
+			D *string   `bind:"d,cookie"`
+			E []string  `bind:"e,cookie" pre:"split"`
+		} `bind:"auto"`
+		Y         string  `bind:"y,cookie,req"`
+		Z         *string `bind:"z,cookie"`
+		W         string  `bind:"w,cookie" default:"def"`
+		V         string  `bind:"v,cookie,req"`
+		SessionID string  `bind:"session_id,auto"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080", nil)
+	req.Header.Add("Cookie", "a=a1; a=a2; b=b1; c=c1; c=c2; d=d1; d=d2")
+	req.Header.Add("Cookie", "e=e1,e2; y=y1; session_id=s1")
+	recv := new(Recv)
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.Equal(t, "parameter required but not found: [v]", err.Error())
+	assert.Equal(t, []string{"a1", "a2"}, (*recv.X).A)
+	assert.Equal(t, "b1", (*recv.X).B)
+	assert.Equal(t, []string{"c1", "c2"}, *(*recv.X).C)
+	assert.Equal(t, "d1", *(*recv.X).D)
+	assert.Equal(t, []string{"e1", "e2"}, (*recv.X).E)
+	assert.Equal(t, "y1", recv.Y)
+	assert.Equal(t, (*string)(nil), recv.Z)
+	assert.Equal(t, "def", recv.W)
+	assert.Equal(t, "s1", recv.SessionID)
+}
+
+func TestCookieNum(t *testing.T) {
+	type Recv struct {
+		X *struct {
+			A []int     `bind:"a,cookie"`
+			B int32     `bind:"b,cookie"`
+			C *[]uint16 `bind:"c,cookie,req"`
+			D *float32  `bind:"d,cookie"`
+		} `bind:"auto"`
+		Y bool   `bind:"y,cookie,req"`
+		Z *int64 `bind:"z,cookie"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080", nil)
+	req.AddCookie(&http.Cookie{Name: "a", Value: "11"})
+	req.AddCookie(&http.Cookie{Name: "a", Value: "12"})
+	req.AddCookie(&http.Cookie{Name: "b", Value: "21"})
+	req.AddCookie(&http.Cookie{Name: "c", Value: "31"})
+	req.AddCookie(&http.Cookie{Name: "c", Value: "32"})
+	req.AddCookie(&http.Cookie{Name: "d", Value: "41"})
+	req.AddCookie(&http.Cookie{Name: "d", Value: "42"})
+	req.AddCookie(&http.Cookie{Name: "y", Value: "true"})
+	recv := new(Recv)
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{11, 12}, (*recv.X).A)
+	assert.Equal(t, int32(21), (*recv.X).B)
+	assert.Equal(t, &[]uint16{31, 32}, (*recv.X).C)
+	assert.Equal(t, float32(41), *(*recv.X).D)
+	assert.Equal(t, true, recv.Y)
+	assert.Equal(t, (*int64)(nil), recv.Z)
+}
+
 func TestFormString(t *testing.T) {
 	type Recv struct {
 		X *struct {
@@ -375,13 +452,13 @@ func TestJSON(t *testing.T) {
 	}
 	type Recv struct {
 		X *struct {
-			A []string  `bind:"a,json"`
-			B int32     `bind:"json"`
-			C *[]uint16 `bind:"json,req"`
-			D *float32  `bind:"d,json"`
-			E metric    `bind:"e,json"`
-			F count     `bind:"f,json"`
-			// M map[string]string `bind:"m,json"`
+			A []string          `bind:"a,json"`
+			B int32             `bind:"json"`
+			C *[]uint16         `bind:"json,req"`
+			D *float32          `bind:"d,json"`
+			E metric            `bind:"e,json"`
+			F count             `bind:"f,json"`
+			M map[string]string `bind:"m,json"`
 		} `bind:"X,json"`
 		Y  string `bind:"y,json,req"`
 		ZS `bind:"auto"`
@@ -411,11 +488,60 @@ func TestJSON(t *testing.T) {
 	assert.Equal(t, float32(41), *(*recv.X).D)
 	assert.Equal(t, metric("qps"), (*recv.X).E)
 	assert.Equal(t, count(100), (*recv.X).F)
-	// assert.Equal(t, map[string]string{"a": "x"}, (*recv.X).M)
+	assert.Equal(t, map[string]string{"a": "x"}, (*recv.X).M)
 	assert.Equal(t, "", recv.Y)
 	assert.Equal(t, (int64)(6), *recv.Z)
 }
 
+func TestJSONPathSyntax(t *testing.T) {
+	type Recv struct {
+		Names []string `bind:"items.#.name,json"`
+		Count int      `bind:"items.#,json"`
+		First string   `bind:"items.#(id==2).name,json"`
+		Tag   string   `bind:"a\\.b,json"`
+	}
+	req, _ := unirest.New().SetURL("http://localhost:8080/").
+		SetJSONBody([]byte(`{"items":[{"id":1,"name":"x"},{"id":2,"name":"y"}],"a.b":"c"}`)).ParseRequest()
+	recv := new(Recv)
+	err := NewBinder().Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "y"}, recv.Names)
+	assert.Equal(t, 2, recv.Count)
+	assert.Equal(t, "y", recv.First)
+	assert.Equal(t, "c", recv.Tag)
+}
+
+func TestJSONMapKeySyntax(t *testing.T) {
+	type Item struct {
+		N int `bind:"n,json"`
+	}
+	type Sub struct {
+		Items []Item `bind:"items,json"`
+	}
+	type Recv struct {
+		M map[string]int `bind:"m,json"`
+		S map[string]Sub `bind:"s,json"`
+	}
+	req, _ := unirest.New().SetURL("http://localhost:8080/").
+		SetJSONBody([]byte(`{"m":{"p|q":1,"a#b":2,"x@y":3,"c.d":4,"e*f?":5,"g\\h":6},"s":{"k#1":{"items":[{"n":7}]}}}`)).ParseRequest()
+	recv := new(Recv)
+	err := NewBinder().Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"p|q": 1, "a#b": 2, "x@y": 3, "c.d": 4, "e*f?": 5, `g\h`: 6}, recv.M)
+	assert.Equal(t, map[string]Sub{"k#1": {Items: []Item{{7}}}}, recv.S)
+
+	// 错误中的 key 与查找时一样转义
+	req, _ = unirest.New().SetURL("http://localhost:8080/").
+		SetJSONBody([]byte(`{"m":{"a.b":"x"},"s":{"c.d":{"items":[{"n":"y"}]}}}`)).ParseRequest()
+	err = NewBinder().Bind(WrapHTTPRequest(req), new(Recv))
+	assert.Error(t, err)
+	var fields []string
+	for _, e := range err.(BindErrors) {
+		fields = append(fields, e.Field)
+	}
+	assert.Equal(t, []string{`m.a\.b`, `s.c\.d.items.0.n`}, fields)
+}
+
 func TestJSON2(t *testing.T) {
 	type site struct {
 		Id         int    `bind:"auto" default:"99"`
@@ -513,6 +639,24 @@ func TestConversionErr(t *testing.T) {
 	assert.Equal(t, "parameter type cannot be converted from string: [Time]", err.Error())
 }
 
+func TestJSONEmptyStruct(t *testing.T) {
+	type Recv struct {
+		Inner struct {
+			A int
+		}
+		List []struct {
+			B int
+		}
+	}
+	req, _ := unirest.New().SetURL("http://localhost:8080/").
+		SetJSONBody([]byte(`{"Inner":{},"List":[{},{"B":2}]}`)).ParseRequest()
+	recv := new(Recv)
+	err := NewBinder().Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(recv.List))
+	assert.Equal(t, 2, recv.List[1].B)
+}
+
 func TestJSONNumInArray(t *testing.T) {
 	type item struct {
 		StrList []string
@@ -577,3 +721,801 @@ func TestJSONInForm(t *testing.T) {
 	assert.Equal(t, "a", recv.Obj.Name)
 	assert.Equal(t, 18, recv.Obj.Age)
 }
+
+func TestPathParam(t *testing.T) {
+	type Recv struct {
+		ID    int    `bind:"id,path,req"`
+		Name  string `bind:"name,path,query"`
+		Page  int    `bind:"page,path" default:"1"`
+		Extra string `bind:"extra,path,req"`
+	}
+	vars := map[string]string{"id": "42", "name": "n1"}
+	options := []WrapOption{
+		WithPathParamFunc(func(req *http.Request, key string) (string, bool) {
+			v, ok := vars[key]
+			return v, ok
+		}),
+		WithPathVars(func(req *http.Request) map[string]string {
+			return vars
+		}),
+		WithPathParamGetter(func(req *http.Request, key string) string {
+			return vars[key]
+		}),
+	}
+	for _, option := range options {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/users/42?name=q1", nil)
+		recv := new(Recv)
+		err := Bind(WrapHTTPRequest(req, option), recv)
+		assert.Error(t, err)
+		assert.Equal(t, "parameter required but not found: [extra]", err.Error())
+		assert.Equal(t, 42, recv.ID)
+		assert.Equal(t, "q1", recv.Name)
+		assert.Equal(t, 1, recv.Page)
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/users/42", nil)
+	recv := new(Recv)
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.Equal(t, "parameter required but not found: [id, extra]", err.Error())
+}
+
+type benchPeople struct {
+	Id   int    `bind:"auto" default:"99"`
+	Name string `bind:"auto,required"`
+	Tags []string
+	Home *struct {
+		City   string `bind:"auto"`
+		Street string `bind:"auto" default:"unknown"`
+	}
+}
+
+type benchRecv struct {
+	A struct {
+		A1 int `bind:"auto"`
+		B  []struct {
+			B1 string `bind:"auto"`
+			B2 string `bind:"auto" default:"def123"`
+			C  []*struct {
+				C1 string `bind:"auto,req"`
+			} `bind:"auto,req"`
+		} `bind:"auto"`
+	} `bind:"auto"`
+	Peoples []*benchPeople `bind:"auto"`
+	Page    int            `bind:"page,query" default:"1"`
+	Token   string         `bind:"X-Token,header"`
+}
+
+var benchBody = []byte(`{"A":{"A1":1,"B":[{"B1":"A.B.1.B1","B2":"A.B.1.B2","C":[{"C1":"c"}]},{"B1":"A.B.2.B1","C":[{"C1":"c"},{"C1":"c"}]}]},` +
+	`"Peoples":[{"Id":1,"Name":"a","Tags":["x","y"],"Home":{"City":"c"}},{"Name":"b"},{"Id":3,"Name":"c","Home":{"City":"c","Street":"s"}}]}`)
+
+func newBenchRequest() Request {
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?page=2", bytes.NewReader(benchBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Token", "token")
+	return WrapHTTPRequest(req)
+}
+
+func TestCache(t *testing.T) {
+	ClearCache()
+	WarmUpCache(&benchRecv{})
+	_, ok := defaultBinder.structMetaCache.Load(reflect.TypeOf(benchRecv{}))
+	assert.True(t, ok)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recv := new(benchRecv)
+			err := Bind(newBenchRequest(), recv)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, recv.Page)
+			assert.Equal(t, 99, recv.Peoples[1].Id)
+			assert.Equal(t, "unknown", recv.Peoples[0].Home.Street)
+			assert.Equal(t, "s", recv.Peoples[2].Home.Street)
+		}()
+	}
+	wg.Wait()
+
+	ClearCache()
+	_, ok = defaultBinder.structMetaCache.Load(reflect.TypeOf(benchRecv{}))
+	assert.False(t, ok)
+}
+
+func BenchmarkBind(b *testing.B) {
+	WarmUpCache(&benchRecv{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		recv := new(benchRecv)
+		if err := Bind(newBenchRequest(), recv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBindUncached(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		recv := new(benchRecv)
+		if err := BindWithStructMeta(newBenchRequest(), recv, ParseStruct(recv)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type benchOrder struct {
+	Id    int               `bind:"id,json"`
+	Items []*benchOrderItem `bind:"items,json"`
+}
+
+type benchOrderItem struct {
+	Name  string           `bind:"name,json"`
+	Price float64          `bind:"price,json"`
+	Tags  []string         `bind:"tags,json"`
+	Parts []*benchItemPart `bind:"parts,json"`
+}
+
+type benchItemPart struct {
+	Sku   string `bind:"sku,json"`
+	Count int    `bind:"count,json"`
+}
+
+// newBenchOrderBody 生成大约 size 字节的订单 json
+func newBenchOrderBody(size int) []byte {
+	item := `{"name":"item","price":9.5,"tags":["a","b"],"parts":[{"sku":"s1","count":1},{"sku":"s2","count":2}]}`
+	var buf bytes.Buffer
+	buf.WriteString(`{"id":1,"items":[`)
+	for i := 0; buf.Len() < size; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(item)
+	}
+	buf.WriteString(`]}`)
+	return buf.Bytes()
+}
+
+func benchmarkBindJSON(b *testing.B, size int) {
+	body := newBenchOrderBody(size)
+	WarmUpCache(&benchOrder{})
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest("POST", "http://localhost:8080/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recv := new(benchOrder)
+		if err := Bind(WrapHTTPRequest(req), recv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBindJSON1KB(b *testing.B)   { benchmarkBindJSON(b, 1<<10) }
+func BenchmarkBindJSON100KB(b *testing.B) { benchmarkBindJSON(b, 100<<10) }
+func BenchmarkBindJSON5MB(b *testing.B)   { benchmarkBindJSON(b, 5<<20) }
+
+func TestBindErrors(t *testing.T) {
+	type People struct {
+		Id   int    `bind:"auto" default:"99"`
+		Name string `bind:"auto,required"`
+	}
+	type Recv struct {
+		Peoples []*People `bind:"auto"`
+		Page    int       `bind:"page,query"`
+		Ids     []int     `bind:"ids,query" pre:"__testErr"`
+		Token   string    `bind:"X-Token,header,query,req"`
+	}
+	req, _ := unirest.New().SetURL("http://localhost:8080/?page=abc&ids=1").
+		SetJSONBody([]byte(`{"Peoples":[{"Name":"a","Id":1},{"Id":2},{}]}`)).ParseRequest()
+	recv := new(Recv)
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, FieldNotFound))
+	assert.True(t, errors.Is(err, FieldConversionError))
+	assert.True(t, errors.Is(err, FieldPreprocessError))
+	assert.Equal(t, "parameter required but not found: [Peoples.1.Name, Peoples.2.Name, X-Token]; "+
+		"parameter type cannot be converted from string: [page]; "+
+		"go-binding error: field=ids, cause=field preprocessor failed: __testErr", err.Error())
+
+	var errs BindErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, 5, len(errs))
+
+	assert.Equal(t, "Peoples.1.Name", errs[0].Field)
+	assert.Equal(t, "auto", errs[0].Source)
+	assert.True(t, errors.Is(errs[0], FieldNotFound))
+	assert.Equal(t, "Peoples.2.Name", errs[1].Field)
+
+	assert.Equal(t, "page", errs[2].Field)
+	assert.Equal(t, "query", errs[2].Source)
+	assert.Equal(t, "abc", errs[2].Value)
+	var numErr *strconv.NumError
+	assert.True(t, errors.As(errs[2], &numErr))
+
+	assert.Equal(t, "ids", errs[3].Field)
+	assert.Equal(t, "1", errs[3].Value)
+	assert.Equal(t, "__testErr", errs[3].Err.Error())
+
+	assert.Equal(t, "X-Token", errs[4].Field)
+	assert.Equal(t, "header,query", errs[4].Source)
+
+	var fieldErr *Error
+	assert.True(t, errors.As(err, &fieldErr))
+	assert.Equal(t, "Peoples.1.Name", fieldErr.Field)
+	assert.Equal(t, 99, recv.Peoples[2].Id)
+}
+
+func TestValidate(t *testing.T) {
+	type People struct {
+		Id    int    `bind:"auto" default:"99" validate:"min=1,max=100"`
+		Name  string `bind:"auto,required" validate:"nonempty,max=5"`
+		Email string `bind:"auto" validate:"email"`
+	}
+	type Recv struct {
+		Peoples []*People `bind:"auto" validate:"min=1"`
+		Page    *uint     `bind:"page,query" validate:"min=1"`
+		Size    int       `bind:"size,query" validate:"max=50"`
+		Order   string    `bind:"order,query" validate:"oneof=asc desc"`
+		Code    string    `bind:"code,query" validate:"len=4,regexp=^[A-Z]+$"`
+		Site    string    `bind:"site,query" validate:"url"`
+		ID      string    `bind:"id,query" validate:"uuid"`
+		Tags    []string  `bind:"tag,query" validate:"max=3,dive,nonempty,max=2"`
+		Score   float64   `bind:"score,query" default:"0.5" validate:"min=0.1"`
+		Unset   int       `bind:"unset,query" validate:"min=1"`
+	}
+	req, _ := unirest.New().SetURL("http://localhost:8080/?page=0&size=51&order=up&code=abcd&site=localhost&id=5d4c2f86-0c9a-4d4b-9a8e-3c4a1b2d3e4f&tag=a&tag=&tag=abc").
+		SetJSONBody([]byte(`{"Peoples":[{"Name":"abc","Id":0,"Email":"a@b.cn"},{"Name":"abcdef","Email":"a"},{"Id":101,"Name":""}]}`)).ParseRequest()
+	recv := new(Recv)
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, FieldValidationError))
+
+	var errs BindErrors
+	assert.True(t, errors.As(err, &errs))
+	fields := make(map[string]string)
+	for _, e := range errs {
+		if errors.Is(e, FieldValidationError) {
+			fields[e.Field] = e.Err.Error()
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"Peoples.0.Id":    "must be at least 1",
+		"Peoples.1.Name":  "length must be at most 5",
+		"Peoples.1.Email": "must be a valid email",
+		"Peoples.2.Id":    "must be at most 100",
+		"Peoples.2.Name":  "must not be empty",
+		"page":            "must be at least 1",
+		"size":            "must be at most 50",
+		"order":           "must be one of [asc desc]",
+		"code":            "must match ^[A-Z]+$",
+		"site":            "must be a valid url",
+		"tag.1":           "must not be empty",
+		"tag.2":           "length must be at most 2",
+	}, fields)
+	assert.Equal(t, 99, recv.Peoples[1].Id)
+
+	req, _ = unirest.New().SetURL("http://localhost:8080/?page=1&order=asc&code=ABCD&site=https://a.cn/x&tag=a").
+		SetJSONBody([]byte(`{"Peoples":[{"Name":"abc","Email":"a@b.cn"}]}`)).ParseRequest()
+	recv = new(Recv)
+	err = Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), *recv.Page)
+}
+
+func TestValidateRegexp(t *testing.T) {
+	type Recv struct {
+		Code string `bind:"code,query" validate:"nonempty, regexp=^[a-z]{1,3}$"`
+		Tag  string `bind:"tag,query" validate:"regexp=^(a|b),[0-9]{2,}$"`
+	}
+	req, _ := unirest.New().SetURL("http://localhost:8080/?code=abcd&tag=a,1").ParseRequest()
+	err := NewBinder().Bind(WrapHTTPRequest(req), new(Recv))
+	var errs BindErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "code", errs[0].Field)
+	assert.Equal(t, "must match ^[a-z]{1,3}$", errs[0].Err.Error())
+	assert.Equal(t, "tag", errs[1].Field)
+	assert.Equal(t, "must match ^(a|b),[0-9]{2,}$", errs[1].Err.Error())
+
+	req, _ = unirest.New().SetURL("http://localhost:8080/?code=abc&tag=b,12").ParseRequest()
+	recv := new(Recv)
+	assert.NoError(t, NewBinder().Bind(WrapHTTPRequest(req), recv))
+	assert.Equal(t, "abc", recv.Code)
+}
+
+func TestValidateInvalidRule(t *testing.T) {
+	assert.Panics(t, func() {
+		ParseStruct(struct {
+			A bool `validate:"min=1"`
+		}{})
+	})
+	assert.Panics(t, func() {
+		ParseStruct(struct {
+			A int `validate:"email"`
+		}{})
+	})
+	assert.Panics(t, func() {
+		ParseStruct(struct {
+			A string `validate:"unknown"`
+		}{})
+	})
+}
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level " + string(text))
+	}
+	return nil
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+func (p *testPoint) UnmarshalJSON(data []byte) error {
+	var xy [2]int
+	if err := js.Unmarshal(data, &xy); err != nil {
+		return err
+	}
+	p.X, p.Y = xy[0], xy[1]
+	return nil
+}
+
+type testUpper string
+
+func (u *testUpper) UnmarshalText(text []byte) error {
+	*u = testUpper(strings.ToUpper(string(text)))
+	return nil
+}
+
+func TestUnmarshaler(t *testing.T) {
+	RegisterTypeConvertor(testUpper(""), func(s string) (interface{}, error) {
+		return testUpper("registered " + s), nil
+	})
+
+	type Recv struct {
+		IP     net.IP       `bind:"ip,query"`
+		IPs    []net.IP     `bind:"ips,query"`
+		Big    *big.Int     `bind:"big,query"`
+		Level  testLevel    `bind:"level,query"`
+		Levels []testLevel  `bind:"levels,json"`
+		Bad    *testLevel   `bind:"bad,query"`
+		Point  testPoint    `bind:"point,json"`
+		Points []*testPoint `bind:"points,json"`
+		Upper  testUpper    `bind:"upper,query"`
+	}
+	req, _ := unirest.New().SetURL("http://localhost:8080/?ip=10.0.0.1&ips=10.0.0.2&ips=::1&big=123456789012345678901234567890&level=high&bad=x&upper=a").
+		SetJSONBody([]byte(`{"levels":["low","high"],"point":[1,2],"points":[[3,4],[5,6]]}`)).ParseRequest()
+	recv := new(Recv)
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.Equal(t, "parameter type cannot be converted from string: [bad]", err.Error())
+	assert.Equal(t, "10.0.0.1", recv.IP.String())
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.2"), net.ParseIP("::1")}, recv.IPs)
+	assert.Equal(t, "123456789012345678901234567890", recv.Big.String())
+	assert.Equal(t, testLevel(2), recv.Level)
+	assert.Equal(t, []testLevel{1, 2}, recv.Levels)
+	assert.Equal(t, testPoint{1, 2}, recv.Point)
+	assert.Equal(t, []*testPoint{{3, 4}, {5, 6}}, recv.Points)
+	assert.Equal(t, testUpper("registered a"), recv.Upper)
+}
+
+func TestSourceOrder(t *testing.T) {
+	type Recv struct {
+		A string `bind:"id,path,query"`
+		B string `bind:"id,query,path"`
+		C string `bind:"id,path,query,ordered"`
+		D string `bind:"id,query,path,ordered"`
+		E string `bind:"name,json,query,ordered"`
+		F string `bind:"name,auto"`
+		G string `bind:"name,auto,ordered"`
+	}
+	newReq := func() Request {
+		req, _ := http.NewRequest("POST", "http://localhost:8080/?id=query&name=query", strings.NewReader(`{"name":"json"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return WrapHTTPRequest(req, WithPathVars(func(*http.Request) map[string]string {
+			return map[string]string{"id": "path"}
+		}))
+	}
+
+	recv := new(Recv)
+	err := Bind(newReq(), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "query", recv.A)
+	assert.Equal(t, "query", recv.B)
+	assert.Equal(t, "path", recv.C)
+	assert.Equal(t, "query", recv.D)
+	assert.Equal(t, "json", recv.E)
+	assert.Equal(t, "query", recv.F)
+	assert.Equal(t, "query", recv.G)
+
+	recv = new(Recv)
+	err = NewBinder(WithTagOrder()).Bind(newReq(), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "path", recv.A)
+	assert.Equal(t, "query", recv.B)
+	assert.Equal(t, "path", recv.C)
+	assert.Equal(t, "query", recv.D)
+	assert.Equal(t, "json", recv.E)
+	assert.Equal(t, "query", recv.F)
+
+	recv = new(Recv)
+	err = NewBinder(WithSourceOrder("json", "path")).Bind(newReq(), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "path", recv.A)
+	assert.Equal(t, "path", recv.B)
+	assert.Equal(t, "path", recv.C)
+	assert.Equal(t, "query", recv.D)
+	assert.Equal(t, "json", recv.F)
+
+	assert.Panics(t, func() {
+		NewBinder(WithSourceOrder("body"))
+	})
+}
+
+func TestMerge(t *testing.T) {
+	type Recv struct {
+		Tags    []string  `bind:"tag,query,json,merge"`
+		Unique  []string  `bind:"tag,query,json,merge,unique"`
+		Ordered *[]string `bind:"tag,json,query,merge,ordered"`
+		First   []string  `bind:"tag,query,json"`
+		Ids     []int     `bind:"id,header,query,json,merge,unique"`
+		Bad     []int     `bind:"bad,query,json,merge"`
+		None    []int     `bind:"none,query,json,merge" default:"1"`
+		Single  string    `bind:"tag,json,query,merge"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?tag=a&tag=b&id=2&id=3&bad=1",
+		strings.NewReader(`{"tag":["b","c"],"id":[3,4],"bad":["x"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Id", "1")
+	recv := new(Recv)
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.Equal(t, "parameter type cannot be converted from string: [bad]", err.Error())
+	var fieldErr *Error
+	assert.True(t, errors.As(err, &fieldErr))
+	assert.Equal(t, "query,json", fieldErr.Source)
+	assert.Equal(t, "x", fieldErr.Value)
+
+	assert.Equal(t, []string{"a", "b", "b", "c"}, recv.Tags)
+	assert.Equal(t, []string{"a", "b", "c"}, recv.Unique)
+	assert.Equal(t, []string{"b", "c", "a", "b"}, *recv.Ordered)
+	assert.Equal(t, []string{"a", "b"}, recv.First)
+	assert.Equal(t, []int{1, 2, 3, 4}, recv.Ids)
+	assert.Equal(t, []int{1}, recv.None)
+	assert.Equal(t, "a", recv.Single)
+}
+
+func TestSourceFieldName(t *testing.T) {
+	type Inner struct {
+		Name string `bind:"name,json"`
+	}
+	type Recv struct {
+		FromHeader string `bind:"auto" header:"X-Request-Id" query:"request_id" json:"requestId"`
+		FromQuery  string `bind:"id,query,json" query:"request_id" json:"rid"`
+		FromJson   string `bind:"json" json:"rid2,omitempty"`
+		Empty      string `bind:"id,json" json:",omitempty"`
+		Session    string `bind:"session,cookie" cookie:"sid"`
+		Form       string `bind:"f,form" form:"form_name"`
+		Inner      Inner  `json:"inner"`
+		Missing    string `bind:"missing,query,required" query:"other"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?request_id=q&missing=x",
+		strings.NewReader(`{"requestId":"j","rid":"r","rid2":"r2","id":"i","inner":{"name":"n"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "h")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "s"})
+	b := NewBinder(WithJSONTag())
+	recv := new(Recv)
+	err := b.Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.Equal(t, "parameter required but not found: [missing]", err.Error())
+
+	assert.Equal(t, "h", recv.FromHeader)
+	assert.Equal(t, "q", recv.FromQuery)
+	assert.Equal(t, "r2", recv.FromJson)
+	assert.Equal(t, "i", recv.Empty)
+	assert.Equal(t, "s", recv.Session)
+	assert.Equal(t, "n", recv.Inner.Name)
+
+	req, _ = http.NewRequest("POST", "http://localhost:8080/", strings.NewReader("form_name=v&f=w"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recv = new(Recv)
+	_ = b.Bind(WrapHTTPRequest(req), recv)
+	assert.Equal(t, "v", recv.Form)
+}
+
+func TestSourceFieldNameErrors(t *testing.T) {
+	var fields []string
+	b := NewBinder(
+		WithPreprocessor("fail", func(origin string) ([]string, error) {
+			return nil, errors.New("fail")
+		}),
+		WithFieldConvertor(testCode(0), FieldConvertorFunc(func(ctx *ConvertContext, s string) (interface{}, error) {
+			fields = append(fields, ctx.Field)
+			return testCode(1), nil
+		})),
+	)
+	type Inner struct {
+		Count int `bind:"count,query" query:"inner_count"`
+	}
+	type Recv struct {
+		Id    int      `bind:"id,query,json" query:"request_id"`
+		Name  string   `bind:"name,header" header:"X-Name" pre:"fail"`
+		Code  testCode `bind:"code,query" query:"c"`
+		Age   int      `bind:"age,query" query:"a" validate:"min=18"`
+		Inner Inner    `bind:"inner"`
+		Json  int      `bind:"json_id,json" query:"q"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?request_id=x&c=1&a=3&inner_count=y",
+		strings.NewReader(`{"json_id":"z"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Name", "n")
+	err := b.Bind(WrapHTTPRequest(req), new(Recv))
+	assert.Error(t, err)
+
+	var paths []string
+	for _, e := range err.(BindErrors) {
+		paths = append(paths, e.Field)
+	}
+	// 错误中是实际查找的名字，json 中为字段的路径
+	assert.ElementsMatch(t, []string{"request_id", "X-Name", "a", "inner_count", "json_id"}, paths)
+	assert.Equal(t, []string{"c"}, fields)
+}
+
+func TestJSONTag(t *testing.T) {
+	type Site struct {
+		Domain string `json:"site_domain,omitempty"`
+	}
+	type Recv struct {
+		Domain  string  `json:"site_domain,omitempty"`
+		Count   int     `json:"count,string"`
+		Secret  string  `json:"-"`
+		Token   string  `bind:"token,query,json" json:"-"`
+		Hidden  string  `bind:"token,json" json:"-"`
+		Site    Site    `json:"site"`
+		Sites   []*Site `json:"sites"`
+		NoTag   string
+		Renamed string `bind:"renamed" json:"other"`
+	}
+	body := `{"site_domain":"a.com","count":"3","Secret":"s","-":"s","token":"j","site":{"site_domain":"b.com"},"sites":[{"site_domain":"c.com"}],"NoTag":"n","renamed":"r","other":"o"}`
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?token=q", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recv := new(Recv)
+	err := NewBinder(WithJSONTag()).Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "a.com", recv.Domain)
+	assert.Equal(t, 3, recv.Count)
+	assert.Equal(t, "", recv.Secret)
+	assert.Equal(t, "q", recv.Token)
+	assert.Equal(t, "", recv.Hidden)
+	assert.Equal(t, "b.com", recv.Site.Domain)
+	assert.Equal(t, "c.com", recv.Sites[0].Domain)
+	assert.Equal(t, "n", recv.NoTag)
+	// bind tag 中的名字优先
+	assert.Equal(t, "r", recv.Renamed)
+
+	// 默认忽略 json tag
+	req, _ = http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(`{"Domain":"a.com","site_domain":"x","Secret":"s","renamed":"r"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recv = new(Recv)
+	err = Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "a.com", recv.Domain)
+	assert.Equal(t, "s", recv.Secret)
+	assert.Equal(t, "r", recv.Renamed)
+}
+
+func TestJSONTagOrder(t *testing.T) {
+	type Recv struct {
+		Secret string `json:"-"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?Secret=q", strings.NewReader(`{"Secret":"s"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recv := new(Recv)
+	err := NewBinder(WithJSONTag(), WithTagOrder()).Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "q", recv.Secret)
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	type Inner struct {
+		Name string
+	}
+	type Recv struct {
+		UserID  string  `bind:"userId,query,nocase"`
+		Exact   string  `bind:"userId,query"`
+		Form    string  `bind:"page,form,nocase"`
+		Json    string  `bind:"nickName,json,nocase"`
+		Nested  string  `bind:"profile.city,json,nocase"`
+		Dup     string  `bind:"dup,query,nocase"`
+		DupJson string  `bind:"Dup,json,nocase"`
+		Prefer  string  `bind:"Same,query,nocase"`
+		Inner   Inner   `bind:"inner"`
+		Items   []Inner `bind:"items"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?UserID=1&DUP=a&Dup=b&same=x&Same=y",
+		strings.NewReader(`{"NickName":"n","Profile":{"city":"c"},"DUP":1,"dUp":2,"Inner":{"Name":"i"},"ITEMS":[{"Name":"a"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	recv := new(Recv)
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, FieldAmbiguousError))
+	errs := err.(BindErrors)
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "dup", errs[0].Field)
+	assert.Equal(t, "query", errs[0].Source)
+	assert.Equal(t, "go-binding error: field=dup, cause=field matches several keys that differ only by case: found DUP, Dup", errs[0].Error())
+	assert.Equal(t, "Dup", errs[1].Field)
+	assert.Equal(t, "json", errs[1].Source)
+
+	assert.Equal(t, "1", recv.UserID)
+	assert.Equal(t, "", recv.Exact)
+	assert.Equal(t, "n", recv.Json)
+	assert.Equal(t, "c", recv.Nested)
+	assert.Equal(t, "", recv.Dup)
+	assert.Equal(t, "", recv.DupJson)
+	assert.Equal(t, "y", recv.Prefer)
+	assert.Equal(t, "", recv.Inner.Name)
+
+	req, _ = http.NewRequest("POST", "http://localhost:8080/", strings.NewReader("PAGE=2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recv = new(Recv)
+	_ = Bind(WrapHTTPRequest(req), recv)
+	assert.Equal(t, "2", recv.Form)
+
+	b := NewBinder(WithCaseInsensitive())
+	req, _ = http.NewRequest("POST", "http://localhost:8080/?USERID=1",
+		strings.NewReader(`{"Inner":{"Name":"i"},"ITEMS":[{"Name":"a"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	recv = new(Recv)
+	err = b.Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", recv.Exact)
+	assert.Equal(t, "i", recv.Inner.Name)
+	assert.Equal(t, "a", recv.Items[0].Name)
+}
+
+func TestJSONNull(t *testing.T) {
+	type People struct {
+		Name string `bind:"name,json"`
+	}
+	type Recv struct {
+		IntPtr  *int      `bind:"int_ptr,json"`
+		StrPtr  *string   `bind:"str_ptr,json"`
+		Int     int       `bind:"int,json,required"`
+		Ints    []int     `bind:"ints,json"`
+		Owner   *People   `bind:"owner"`
+		Peoples []*People `bind:"peoples"`
+		Min     *int      `bind:"min,json" validate:"min=1"`
+		NotNull *string   `bind:"not_null,json,notnull"`
+		Query   *string   `bind:"q,query,json,ordered"`
+		Merged  []string  `bind:"tag,query,json,merge"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?q=x&tag=a",
+		strings.NewReader(`{"int_ptr":null,"str_ptr":null,"int":null,"ints":null,"owner":null,"peoples":null,"min":null,"not_null":null,"q":null,"tag":null}`))
+	req.Header.Set("Content-Type", "application/json")
+	recv := &Recv{Ints: []int{1}}
+	presence, err := NewBinder().BindPresence(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, FieldNullError))
+	assert.Equal(t, "go-binding error: field=not_null, cause=field can't be null", err.Error())
+
+	assert.Nil(t, recv.IntPtr)
+	assert.Nil(t, recv.StrPtr)
+	assert.Equal(t, 0, recv.Int)
+	assert.Nil(t, recv.Ints)
+	assert.Nil(t, recv.Owner)
+	assert.Nil(t, recv.Peoples)
+	assert.Nil(t, recv.Min)
+	assert.Equal(t, "x", *recv.Query)
+	assert.Equal(t, []string{"a"}, recv.Merged)
+
+	assert.Equal(t, FieldPresence{Source: "json", Null: true}, presence["int_ptr"])
+	assert.Equal(t, FieldPresence{Source: "json", Null: true}, presence["owner"])
+	assert.Equal(t, FieldPresence{Source: "json", Null: true}, presence["peoples"])
+	assert.Equal(t, FieldPresence{Source: "query"}, presence["q"])
+	assert.Equal(t, FieldPresence{Source: "query,json"}, presence["tag"])
+}
+
+func TestMap(t *testing.T) {
+	type Item struct {
+		Name  string `bind:"name,json,required"`
+		Count int    `bind:"count,json" default:"1"`
+	}
+	type Recv struct {
+		Labels   map[string]string         `bind:"labels,json"`
+		Scores   map[string]int            `bind:"scores,json"`
+		Lists    map[string][]int          `bind:"lists,json"`
+		Ptrs     *map[string]*int          `bind:"ptrs,json"`
+		Items    map[string]Item           `bind:"items,json"`
+		ItemPtrs map[string]*Item          `bind:"items,json"`
+		Meta     map[string]string         `bind:"meta,query"`
+		Filters  map[string][]string       `bind:"query,form" prefix:"f_"`
+		Codes    map[int]string            `bind:"codes,query"`
+		Headers  map[string][]string       `bind:"header"`
+		Trace    map[string]string         `prefix:"X-Trace-"`
+		Missing  map[string]string         `bind:"missing,json,required"`
+		Null     map[string]string         `bind:"null,json"`
+		Bad      map[string]int            `bind:"bad,json"`
+		Time     map[string]time.Time      `bind:"time,json" format:"2006-01-02"`
+		Raw      map[string]testPoint      `bind:"raw,json"`
+		Nested   map[string]map[string]int `bind:"nested,json"`
+		Sizes    map[string]string         `bind:"labels,json" validate:"max=1"`
+		Unused   map[string]js.RawMessage  `bind:"-"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?meta[color]=red&meta[size]=L&f_tag=a&f_tag=b&codes[1]=one&codes[x]=bad",
+		strings.NewReader(`{
+			"labels": {"a": "x", "b": "y"},
+			"scores": {"a": 1},
+			"lists": {"a": [1, 2], "b": 3},
+			"ptrs": {"a": 1},
+			"items": {"first": {"name": "n", "count": 2}, "second": {"count": 3}},
+			"null": null,
+			"bad": {"a": "x"},
+			"time": {"a": "2021-08-04"},
+			"raw": {"p": [1, 2]},
+			"nested": {"a": {"b": 1}}
+		}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trace-Id", "t1")
+	recv := &Recv{Null: map[string]string{"a": "b"}}
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	var fields []string
+	for _, e := range err.(BindErrors) {
+		fields = append(fields, e.Field)
+	}
+	assert.Equal(t, []string{"items.second.name", "items.second.name", "codes.x", "missing", "bad.a", "nested.a", "labels"}, fields)
+
+	assert.Equal(t, map[string]string{"a": "x", "b": "y"}, recv.Labels)
+	assert.Equal(t, map[string]int{"a": 1}, recv.Scores)
+	assert.Equal(t, map[string][]int{"a": {1, 2}, "b": {3}}, recv.Lists)
+	assert.Equal(t, 1, *(*recv.Ptrs)["a"])
+	assert.Equal(t, map[string]Item{"first": {"n", 2}, "second": {"", 3}}, recv.Items)
+	assert.Equal(t, Item{"n", 2}, *recv.ItemPtrs["first"])
+	assert.Equal(t, map[string]string{"color": "red", "size": "L"}, recv.Meta)
+	assert.Equal(t, map[string][]string{"tag": {"a", "b"}}, recv.Filters)
+	assert.Equal(t, map[int]string{1: "one"}, recv.Codes)
+	assert.Equal(t, []string{"t1"}, recv.Headers["X-Trace-Id"])
+	assert.Equal(t, []string{"application/json"}, recv.Headers["Content-Type"])
+	assert.Equal(t, map[string]string{"Id": "t1"}, recv.Trace)
+	assert.Nil(t, recv.Null)
+	assert.Equal(t, time.Date(2021, 8, 4, 0, 0, 0, 0, time.UTC), recv.Time["a"])
+	assert.Equal(t, testPoint{1, 2}, recv.Raw["p"])
+}
+
+func TestJSONContentType(t *testing.T) {
+	type Recv struct {
+		Name string `bind:"name,json"`
+		Dot  string `bind:"a\\.b,json"`
+	}
+	for contentType, expected := range map[string]string{
+		"":                                "n",
+		"application/json; charset=utf-8": "n",
+		"application/merge-patch+json":    "n",
+		"text/plain":                      "",
+	} {
+		req, _ := http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(`{"name":"n","a.b":"d"}`))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		recv := new(Recv)
+		err := Bind(WrapHTTPRequest(req), recv)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, recv.Name, contentType)
+		if expected != "" {
+			assert.Equal(t, "d", recv.Dot, contentType)
+		}
+	}
+}
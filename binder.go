@@ -0,0 +1,265 @@
+package binding
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Binder binds requests to structs. Each Binder has its own convertors,
+// preprocessors, metadata cache and options, the package level functions use
+// a default Binder. A Binder is safe for concurrent use.
+type Binder struct {
+	// mu 保护 convertMap, interfaceConverts, processorMap, decoderMap
+	mu sync.RWMutex
+
+	// convertMap 通过 RegisterTypeConvertor, RegisterFieldConvertor 注册的 convertor
+	convertMap map[reflect.Type]FieldConvertor
+
+	// interfaceConverts 注册给接口的 convertor，按注册顺序匹配
+	interfaceConverts []interfaceConvertor
+
+	processorMap map[string]Processor
+
+	// decoderMap 按 media type 注册的 BodyDecoder
+	decoderMap map[string]BodyDecoder
+
+	// structMetaCache 缓存每个结构体类型解析后的 StructMetadata, key 为 reflect.Type
+	structMetaCache sync.Map
+
+	// sourceOrder auto 以及多个来源时获取值的顺序
+	sourceOrder []int
+
+	// tagOrder 所有字段都按 bind tag 中的书写顺序获取值
+	tagOrder bool
+
+	// jsonTag 是否使用 json tag 作为 json 中的名字
+	jsonTag bool
+
+	// naming 没有指定名字的字段在每个来源中的命名方式
+	naming map[int]NamingStrategy
+
+	// noCase 所有字段的 query, form, json key 都不区分大小写
+	noCase bool
+
+	// strict 不为 nil 时检查请求中没有字段使用的 key
+	strict *strictChecker
+
+	// bodyWarn 不为 nil 时 body 无法解码的错误交给 bodyWarn
+	bodyWarn func(err *Error)
+}
+
+// Option configures a Binder created by NewBinder.
+type Option func(b *Binder)
+
+// WithTypeConvertor registers a convertor, see Binder.RegisterTypeConvertor.
+func WithTypeConvertor(target interface{}, convertor Convertor) Option {
+	return func(b *Binder) {
+		b.RegisterTypeConvertor(target, convertor)
+	}
+}
+
+// WithFieldConvertor registers a convertor, see Binder.RegisterFieldConvertor.
+func WithFieldConvertor(target interface{}, convertor FieldConvertor) Option {
+	return func(b *Binder) {
+		b.RegisterFieldConvertor(target, convertor)
+	}
+}
+
+// WithPreprocessor registers a preprocessor, see Binder.RegisterPreprocessor.
+func WithPreprocessor(name string, processor Processor) Option {
+	return func(b *Binder) {
+		b.RegisterPreprocessor(name, processor)
+	}
+}
+
+// WithBodyDecoder registers a body decoder, see Binder.RegisterBodyDecoder.
+func WithBodyDecoder(mediaType string, decoder BodyDecoder) Option {
+	return func(b *Binder) {
+		b.RegisterBodyDecoder(mediaType, decoder)
+	}
+}
+
+// WithBodyDecoders registers a body decoder for several media types, see
+// Binder.RegisterBodyDecoders.
+func WithBodyDecoders(decoder BodyDecoder, mediaTypes ...string) Option {
+	return func(b *Binder) {
+		b.RegisterBodyDecoders(decoder, mediaTypes...)
+	}
+}
+
+// WithBodyWarn passes to warn the bodies that Bind skips: a non-empty body
+// whose decoder fails as BodyDecodeError, and one whose media type has no
+// decoder as BodyUnsupportedError. Form and XML bodies are not reported. The
+// Field of the error is the Content-Type of the request.
+func WithBodyWarn(warn func(err *Error)) Option {
+	return func(b *Binder) {
+		b.bodyWarn = warn
+	}
+}
+
+// WithSourceOrder sets the order in which auto and fields with several
+// sources look for a value, e.g. WithSourceOrder("json", "path"). Sources not
+// listed follow in the default order header, cookie, query, path, form, json, xml.
+func WithSourceOrder(sources ...string) Option {
+	return func(b *Binder) {
+		order := make([]int, 0, len(sourceOrder))
+		for _, name := range sources {
+			source, ok := sourceMap[name]
+			if !ok || source == auto {
+				panic("go-binding: unknown source " + name)
+			}
+			order = append(order, source)
+		}
+		for _, source := range sourceOrder {
+			if !containsSource(order, source) {
+				order = append(order, source)
+			}
+		}
+		b.sourceOrder = order
+	}
+}
+
+// WithTagOrder makes every field look for a value in the order its sources
+// are written in the bind tag, like the ordered option of the bind tag.
+func WithTagOrder() Option {
+	return func(b *Binder) {
+		b.tagOrder = true
+	}
+}
+
+// WithCaseInsensitive matches query, form and top-level JSON keys ignoring
+// case for every field, like the nocase option of the bind tag.
+func WithCaseInsensitive() Option {
+	return func(b *Binder) {
+		b.noCase = true
+	}
+}
+
+// WithJSONTag reads names in the JSON body from the standard json tag of
+// fields whose bind tag has no name, json:"-" stops reading the field from
+// the body.
+func WithJSONTag() Option {
+	return func(b *Binder) {
+		b.jsonTag = true
+	}
+}
+
+// WithNamingStrategy maps the names of fields without an explicit name to
+// the given sources, e.g. WithNamingStrategy(SnakeCase, "query", "form").
+// With no sources it applies to all of them. A name in the bind tag or in a
+// per-source tag takes priority.
+func WithNamingStrategy(strategy NamingStrategy, sources ...string) Option {
+	return func(b *Binder) {
+		if len(sources) == 0 {
+			sources = []string{bindAuto}
+		}
+		if b.naming == nil {
+			b.naming = make(map[int]NamingStrategy)
+		}
+		for _, name := range sources {
+			source, ok := sourceMap[name]
+			if !ok {
+				panic("go-binding: unknown source " + name)
+			}
+			for _, s := range sourceOrder {
+				if hasTag(source, s) {
+					b.naming[s] = strategy
+				}
+			}
+		}
+	}
+}
+
+func NewBinder(opts ...Option) *Binder {
+	b := &Binder{
+		convertMap:   map[reflect.Type]FieldConvertor{},
+		processorMap: make(map[string]Processor, len(defaultProcessors)),
+		decoderMap:   make(map[string]BodyDecoder, len(defaultBodyDecoders)),
+		sourceOrder:  sourceOrder,
+	}
+	for name, processor := range defaultProcessors {
+		b.processorMap[name] = processor
+	}
+	for mediaType, decoder := range defaultBodyDecoders {
+		b.decoderMap[mediaType] = decoder
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+var defaultBinder = NewBinder()
+
+func (b *Binder) getStructMeta(t reflect.Type) *StructMetadata {
+	if sm, ok := b.structMetaCache.Load(t); ok {
+		return sm.(*StructMetadata)
+	}
+	sm, _ := b.structMetaCache.LoadOrStore(t, b.parseStruct(&t, "", ""))
+	return sm.(*StructMetadata)
+}
+
+// WarmUpCache parses the given structs (or pointers to them) and caches their
+// metadata, so the first Bind of each type doesn't pay for it.
+func (b *Binder) WarmUpCache(structs ...interface{}) {
+	for _, s := range structs {
+		t := reflect.TypeOf(s)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		b.getStructMeta(t)
+	}
+}
+
+// ClearCache drops all the cached struct metadata.
+func (b *Binder) ClearCache() {
+	b.structMetaCache.Range(func(key, _ interface{}) bool {
+		b.structMetaCache.Delete(key)
+		return true
+	})
+}
+
+func Bind(r Request, recvPtr interface{}) error {
+	return defaultBinder.Bind(r, recvPtr)
+}
+
+func BindWithStructMeta(r Request, recvPtr interface{}, structMeta *StructMetadata) error {
+	return defaultBinder.BindWithStructMeta(r, recvPtr, structMeta)
+}
+
+func RegisterTypeConvertor(target interface{}, convertor Convertor) {
+	defaultBinder.RegisterTypeConvertor(target, convertor)
+}
+
+func RegisterFieldConvertor(target interface{}, convertor FieldConvertor) {
+	defaultBinder.RegisterFieldConvertor(target, convertor)
+}
+
+func RegisterPreprocessor(name string, processor Processor) {
+	defaultBinder.RegisterPreprocessor(name, processor)
+}
+
+func RegisterBodyDecoder(mediaType string, decoder BodyDecoder) {
+	defaultBinder.RegisterBodyDecoder(mediaType, decoder)
+}
+
+func RegisterBodyDecoders(decoder BodyDecoder, mediaTypes ...string) {
+	defaultBinder.RegisterBodyDecoders(decoder, mediaTypes...)
+}
+
+func WarmUpCache(structs ...interface{}) {
+	defaultBinder.WarmUpCache(structs...)
+}
+
+func ClearCache() {
+	defaultBinder.ClearCache()
+}
+
+func containsSource(sources []int, source int) bool {
+	for _, s := range sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
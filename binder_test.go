@@ -0,0 +1,84 @@
+package binding
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinderIsolation(t *testing.T) {
+	type code int
+	type Recv struct {
+		Code code     `bind:"code,query"`
+		Tags []string `bind:"tags,query" pre:"upper"`
+	}
+
+	b1 := NewBinder(
+		WithTypeConvertor(code(0), func(s string) (interface{}, error) {
+			v, err := strconv.Atoi(s)
+			return code(v + 1), err
+		}),
+		WithPreprocessor("upper", func(origin string) ([]string, error) {
+			return []string{strings.ToUpper(origin)}, nil
+		}),
+	)
+	b2 := NewBinder()
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/?code=1&tags=a", nil)
+	recv := new(Recv)
+	err := b1.Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, code(2), recv.Code)
+	assert.Equal(t, []string{"A"}, recv.Tags)
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/?code=1&tags=a", nil)
+	recv = new(Recv)
+	err = b2.Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, code(1), recv.Code)
+	assert.Equal(t, []string{"a"}, recv.Tags)
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/?code=1&tags=a,b", nil)
+	recv = new(Recv)
+	err = b2.BindWithStructMeta(WrapHTTPRequest(req), recv, ParseStruct(recv))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a,b"}, recv.Tags)
+}
+
+func TestBinderConcurrentRegister(t *testing.T) {
+	type code int
+	type Recv struct {
+		Code code     `bind:"code,query"`
+		Tags []string `bind:"tags,query" pre:"split"`
+	}
+
+	b := NewBinder()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			b.RegisterTypeConvertor(code(0), func(s string) (interface{}, error) {
+				v, err := strconv.Atoi(s)
+				return code(v), err
+			})
+			b.RegisterPreprocessor("split", func(origin string) ([]string, error) {
+				return strings.Split(origin, ","), nil
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "http://localhost:8080/?code=1&tags=a,b", nil)
+			recv := new(Recv)
+			err := b.Bind(WrapHTTPRequest(req), recv)
+			assert.NoError(t, err)
+			assert.Equal(t, code(1), recv.Code)
+			assert.Equal(t, []string{"a", "b"}, recv.Tags)
+		}()
+	}
+	wg.Wait()
+}
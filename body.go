@@ -0,0 +1,223 @@
+package binding
+
+import (
+	"bytes"
+	"math"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+const mimeJSON = "application/json"
+
+// defaultBodyDecoders 每个 Binder 自带的 body 解码器，key 为 media type
+var defaultBodyDecoders = map[string]BodyDecoder{
+	mimeJSON: BodyDecoderFunc(decodeJSON),
+}
+
+// RegisterBodyDecoder registers the decoder of bodies with the given media
+// type, e.g. "application/yaml", replacing the built-in one if any. The
+// decoder registered for "application/json" is also used for "+json" media
+// types without their own decoder and for requests without a Content-Type.
+func (b *Binder) RegisterBodyDecoder(mediaType string, decoder BodyDecoder) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.decoderMap[strings.ToLower(mediaType)] = decoder
+}
+
+// RegisterBodyDecoders registers decoder for each of mediaTypes, e.g. the
+// Decoder and MediaTypes of the yamlbody package.
+func (b *Binder) RegisterBodyDecoders(decoder BodyDecoder, mediaTypes ...string) {
+	for _, mediaType := range mediaTypes {
+		b.RegisterBodyDecoder(mediaType, decoder)
+	}
+}
+
+func (b *Binder) getBodyDecoder(mediaType string) BodyDecoder {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if d, ok := b.decoderMap[mediaType]; ok {
+		return d
+	}
+	if mediaType == "" || strings.HasSuffix(mediaType, "+json") {
+		return b.decoderMap[mimeJSON]
+	}
+	return nil
+}
+
+// decodeBody 用 Content-Type 对应的解码器解码 body，没有解码器或者解码失败时返回 nil，
+// 非空的 body 没有解码器或者解码失败时交给 bodyWarn
+func (b *Binder) decodeBody(contentType string, body []byte) Document {
+	var mediaType string
+	if contentType != "" {
+		var err error
+		mediaType, _, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			b.warnBody(BodyDecodeError, contentType, body, err)
+			return nil
+		}
+	}
+	decoder := b.getBodyDecoder(mediaType)
+	if decoder == nil {
+		if !isFormMediaType(mediaType) && !isXMLContentType(mediaType) {
+			b.warnBody(BodyUnsupportedError, contentType, body, nil)
+		}
+		return nil
+	}
+	doc, err := decoder.Decode(contentType, body)
+	if err != nil {
+		b.warnBody(BodyDecodeError, contentType, body, err)
+		return nil
+	}
+	return doc
+}
+
+// warnBody 把非空 body 的错误交给 bodyWarn
+func (b *Binder) warnBody(e *Error, contentType string, body []byte, err error) {
+	if b.bodyWarn == nil || len(bytes.TrimSpace(body)) == 0 {
+		return
+	}
+	b.bodyWarn(e.with(contentType, bindJson, "", err))
+}
+
+// isFormMediaType form 的 body 由 form 来源获取
+func isFormMediaType(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+func decodeJSON(_ string, body []byte) (Document, error) {
+	return NewJSONDocument(body)
+}
+
+// convertBodyValue 把 Document 中带类型的值直接转为字段的类型，整数不经过 string，字节串绑定到 []byte。
+// 有 preprocessor、format 或者注册了 convertor 时，以及类型不匹配、溢出时返回 false，
+// 由 string 数据转换，转换错误与 json 一样按字段的路径记录
+func (b *Binder) convertBodyValue(fieldMeta *fieldMetadata, elemType reflect.Type) (reflect.Value, bool) {
+	raw := fieldMeta.bodyValue
+	if raw == nil || fieldMeta.valueSource != bindJson || fieldMeta.rawJSON || fieldMeta.isUnique ||
+		b.hasPreprocessor(fieldMeta) || fieldMeta.format != "" || b.hasConvertor(elemType) {
+		return reflect.Value{}, false
+	}
+	if !fieldMeta.isSlice {
+		return bodyScalar(raw, elemType)
+	}
+
+	sliceMeta := fieldMeta.sliceMeta
+	if v := reflect.ValueOf(raw); v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		if elemType.Kind() != reflect.Uint8 || sliceMeta.isPtr {
+			return reflect.Value{}, false
+		}
+		return v.Convert(sliceMeta.sliceType), true
+	}
+	elems, ok := raw.([]interface{})
+	if !ok {
+		elems = []interface{}{raw}
+	}
+	value := reflect.MakeSlice(sliceMeta.sliceType, len(elems), len(elems))
+	for i, elem := range elems {
+		v, ok := bodyScalar(elem, elemType)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		if sliceMeta.isPtr {
+			ptr := reflect.New(elemType)
+			ptr.Elem().Set(v)
+			v = ptr
+		}
+		value.Index(i).Set(v)
+	}
+	return value, true
+}
+
+// hasPreprocessor 字段是否使用了注册的 preprocessor
+func (b *Binder) hasPreprocessor(fieldMeta *fieldMetadata) bool {
+	for _, name := range fieldMeta.preprocessor {
+		if _, ok := b.getPreprocessor(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasConvertor 类型是否有注册的或者内置的类型 convertor
+func (b *Binder) hasConvertor(t reflect.Type) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.getRegisteredConvertor(t) != nil
+}
+
+// bodyScalar 转换一个标量，类型相同时直接使用，如 time.Time
+func bodyScalar(raw interface{}, t reflect.Type) (reflect.Value, bool) {
+	v := reflect.ValueOf(raw)
+	if !v.IsValid() {
+		return reflect.Value{}, false
+	}
+	if v.Type() == t {
+		return v, true
+	}
+	if isTextUnmarshaler(t) {
+		return reflect.Value{}, false
+	}
+
+	out := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = v.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v.Uint() > math.MaxInt64 {
+				return reflect.Value{}, false
+			}
+			n = int64(v.Uint())
+		default:
+			return reflect.Value{}, false
+		}
+		if out.OverflowInt(n) {
+			return reflect.Value{}, false
+		}
+		out.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch v.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = v.Uint()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.Int() < 0 {
+				return reflect.Value{}, false
+			}
+			n = uint64(v.Int())
+		default:
+			return reflect.Value{}, false
+		}
+		if out.OverflowUint(n) {
+			return reflect.Value{}, false
+		}
+		out.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			f = v.Float()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f = float64(v.Uint())
+		default:
+			return reflect.Value{}, false
+		}
+		if out.OverflowFloat(f) {
+			return reflect.Value{}, false
+		}
+		out.SetFloat(f)
+	case reflect.Bool:
+		if v.Kind() != reflect.Bool {
+			return reflect.Value{}, false
+		}
+		out.SetBool(v.Bool())
+	default:
+		return reflect.Value{}, false
+	}
+	return out, true
+}
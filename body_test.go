@@ -0,0 +1,266 @@
+package binding
+
+import (
+	js "encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newBodyRequest(contentType, body string) Request {
+	req, _ := http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return WrapHTTPRequest(req)
+}
+
+// flatDocument 每个路径对应一个 string，没有对象和数组
+type flatDocument map[string]string
+
+func (d flatDocument) Get(path string) (DocumentValue, bool) {
+	v, ok := d[path]
+	return DocumentValue{Text: v}, ok
+}
+
+func (d flatDocument) Array(path string) []DocumentValue {
+	if v, ok := d.Get(path); ok {
+		return []DocumentValue{v}
+	}
+	return nil
+}
+
+func (d flatDocument) Keys(string) []string {
+	return nil
+}
+
+func (d flatDocument) Exists(path string) bool {
+	_, ok := d[path]
+	return ok
+}
+
+func TestBodyDecoder(t *testing.T) {
+	type Req struct {
+		Name  string `bind:"name,json,required"`
+		Count int64  `bind:"count,json"`
+		Tags  []int  `bind:"tags,json"`
+		Inner struct {
+			Id int `bind:"id,json,required"`
+		} `bind:"inner"`
+	}
+
+	envelope := BodyDecoderFunc(func(contentType string, body []byte) (Document, error) {
+		var e struct {
+			Data js.RawMessage `json:"data"`
+		}
+		if err := js.Unmarshal(body, &e); err != nil {
+			return nil, err
+		}
+		return NewJSONDocument(e.Data)
+	})
+	lines := BodyDecoderFunc(func(contentType string, body []byte) (Document, error) {
+		doc := flatDocument{}
+		for _, line := range strings.Split(string(body), "\n") {
+			if kv := strings.SplitN(line, "=", 2); len(kv) == 2 {
+				doc[kv[0]] = kv[1]
+			}
+		}
+		return doc, nil
+	})
+	typed := BodyDecoderFunc(func(contentType string, body []byte) (Document, error) {
+		return NewTreeDocument(map[interface{}]interface{}{
+			"name":  string(body),
+			"count": int32(7),
+			"tags":  []interface{}{uint8(1), 2},
+			"inner": map[string]interface{}{"id": 3},
+		}), nil
+	})
+	b := NewBinder(
+		WithBodyDecoder("application/vnd.envelope+json", envelope),
+		WithBodyDecoder("text/plain", lines),
+	)
+	b.RegisterBodyDecoder("Application/X-Typed", typed)
+
+	recv := new(Req)
+	body := `{"data": {"name": "a", "count": 1, "tags": [1, 2], "inner": {"id": 2}}, "name": "b"}`
+	err := b.Bind(newBodyRequest("application/vnd.envelope+json; charset=utf-8", body), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", recv.Name)
+	assert.Equal(t, int64(1), recv.Count)
+	assert.Equal(t, []int{1, 2}, recv.Tags)
+	assert.Equal(t, 2, recv.Inner.Id)
+
+	// 其他 +json 以及默认的 Binder 仍然使用 json
+	recv = new(Req)
+	err = b.Bind(newBodyRequest("application/problem+json", body), recv)
+	assert.Equal(t, "parameter required but not found: [inner.id]", err.Error())
+	assert.Equal(t, "b", recv.Name)
+	recv = new(Req)
+	err = NewBinder().Bind(newBodyRequest("application/vnd.envelope+json", body), recv)
+	assert.Equal(t, "b", recv.Name)
+
+	recv = new(Req)
+	err = b.Bind(newBodyRequest("text/plain", "name=c\ncount=x\ninner.id=4"), recv)
+	assert.Equal(t, "parameter type cannot be converted from string: [count]", err.Error())
+	assert.Equal(t, "c", recv.Name)
+	assert.Equal(t, 4, recv.Inner.Id)
+
+	recv = new(Req)
+	err = b.Bind(newBodyRequest("application/x-typed", "d"), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "d", recv.Name)
+	assert.Equal(t, int64(7), recv.Count)
+	assert.Equal(t, []int{1, 2}, recv.Tags)
+	assert.Equal(t, 3, recv.Inner.Id)
+
+	// 替换默认的 json 解码器
+	b.RegisterBodyDecoder("application/json", envelope)
+	recv = new(Req)
+	err = b.Bind(newBodyRequest("application/json", body), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", recv.Name)
+}
+
+func TestBodyWarn(t *testing.T) {
+	type Req struct {
+		Name string `bind:"name,json"`
+	}
+	var warns []*Error
+	b := NewBinder(WithBodyWarn(func(err *Error) {
+		warns = append(warns, err)
+	}))
+
+	recv := new(Req)
+	err := b.Bind(newBodyRequest("application/yaml", "name: a"), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "", recv.Name)
+	assert.Equal(t, 1, len(warns))
+	assert.True(t, errors.Is(warns[0], BodyUnsupportedError))
+	assert.Equal(t, "application/yaml", warns[0].Field)
+
+	err = b.Bind(newBodyRequest("application/json", `{"name": `), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(warns))
+	assert.True(t, errors.Is(warns[1], BodyDecodeError))
+	assert.Equal(t, "go-binding error: content-type=application/json, cause=body can't be decoded: invalid json", warns[1].Error())
+
+	err = b.Bind(newBodyRequest("application/json; charset", `{}`), recv)
+	assert.True(t, errors.Is(warns[2], BodyDecodeError))
+
+	// 空 body, form 以及 xml 不报告
+	b.Bind(newBodyRequest("application/yaml", " "), recv)
+	b.Bind(newBodyRequest("", ""), recv)
+	b.Bind(newBodyRequest("application/x-www-form-urlencoded", "name=a"), recv)
+	b.Bind(newBodyRequest("application/xml", "<r><name>a</name></r>"), recv)
+	assert.Equal(t, 3, len(warns))
+
+	// 没有 WithBodyWarn 时忽略
+	err = NewBinder().Bind(newBodyRequest("application/yaml", "name: a"), recv)
+	assert.NoError(t, err)
+}
+
+func TestTreeDocument(t *testing.T) {
+	type Req struct {
+		Id       int64             `bind:"id,json"`
+		Serial   uint64            `bind:"serial,json"`
+		Payload  []byte            `bind:"payload,json"`
+		Temp     float32           `bind:"temp,json"`
+		Readings []int32           `bind:"readings,json"`
+		Tags     []string          `bind:"tags,json" pre:"split"`
+		Port     int               `bind:"port,json" default:"80"`
+		Level    int8              `bind:"level,json"`
+		Seen     time.Time         `bind:"seen,json"`
+		Labels   map[string]string `bind:"labels,json"`
+		Servers  []*struct {
+			Host  string `bind:"host,json,required"`
+			Port  int    `bind:"port,json" default:"8080"`
+			Level *uint8 `bind:"level,json"`
+		} `bind:"servers"`
+	}
+	seen := time.Date(2021, 8, 4, 10, 0, 0, 5, time.UTC)
+	tree := BodyDecoderFunc(func(contentType string, body []byte) (Document, error) {
+		return NewTreeDocument(map[string]interface{}{
+			"id":       int64(math.MaxInt64),
+			"serial":   uint64(math.MaxUint64),
+			"payload":  []byte{0xff, 0x00, 0xfe},
+			"temp":     21.5,
+			"readings": []interface{}{1, -2, 3},
+			"tags":     "a,b",
+			"level":    300,
+			"seen":     seen,
+			"labels":   map[interface{}]interface{}{"env": "prod", 1: "one"},
+			"servers": []interface{}{
+				map[string]interface{}{"host": "h1", "level": 7},
+				map[string]interface{}{"port": 82, "level": -1},
+			},
+		}), nil
+	})
+	recv := new(Req)
+	err := NewBinder(WithBodyDecoder("application/x-tree", tree)).Bind(newBodyRequest("application/x-tree", "-"), recv)
+	errs := err.(BindErrors)
+	assert.Equal(t, 3, len(errs))
+	assert.True(t, errors.Is(errs[0], FieldConversionError))
+	assert.Equal(t, "level", errs[0].Field)
+	assert.Equal(t, "300", errs[0].Value)
+	assert.True(t, errors.Is(errs[1], FieldNotFound))
+	assert.Equal(t, "servers.1.host", errs[1].Field)
+	assert.True(t, errors.Is(errs[2], FieldConversionError))
+	assert.Equal(t, "servers.1.level", errs[2].Field)
+
+	assert.Equal(t, int64(math.MaxInt64), recv.Id)
+	assert.Equal(t, uint64(math.MaxUint64), recv.Serial)
+	assert.Equal(t, []byte{0xff, 0x00, 0xfe}, recv.Payload)
+	assert.Equal(t, float32(21.5), recv.Temp)
+	assert.Equal(t, []int32{1, -2, 3}, recv.Readings)
+	assert.Equal(t, []string{"a", "b"}, recv.Tags)
+	assert.Equal(t, 80, recv.Port)
+	assert.True(t, seen.Equal(recv.Seen))
+	assert.Equal(t, map[string]string{"env": "prod", "1": "one"}, recv.Labels)
+	assert.Equal(t, "h1", recv.Servers[0].Host)
+	assert.Equal(t, 8080, recv.Servers[0].Port)
+	assert.Equal(t, uint8(7), *recv.Servers[0].Level)
+	assert.Equal(t, 82, recv.Servers[1].Port)
+}
+
+func TestTreeDocumentPathSyntax(t *testing.T) {
+	type Recv struct {
+		Names []string `bind:"items.#.name,json"`
+		Count int      `bind:"items.#,json"`
+		First string   `bind:"items.#(id==2).name,json"`
+		Tag   string   `bind:"a\\.b,json"`
+	}
+	tree := BodyDecoderFunc(func(contentType string, body []byte) (Document, error) {
+		return NewTreeDocument(map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"id": 1, "name": "x"},
+				map[string]interface{}{"id": 2, "name": "y"},
+			},
+			"a.b": "c",
+		}), nil
+	})
+	recv := new(Recv)
+	err := NewBinder(WithBodyDecoder("application/x-tree", tree)).Bind(newBodyRequest("application/x-tree", ""), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "y"}, recv.Names)
+	assert.Equal(t, 2, recv.Count)
+	assert.Equal(t, "y", recv.First)
+	assert.Equal(t, "c", recv.Tag)
+}
+
+func TestTreeDocumentMapKeySyntax(t *testing.T) {
+	type Recv struct {
+		M map[string]int `bind:"m,json"`
+	}
+	tree := BodyDecoderFunc(func(contentType string, body []byte) (Document, error) {
+		return NewTreeDocument(map[string]interface{}{
+			"m": map[string]interface{}{"p|q": 1, "a#b": 2, "x@y": 3, "c.d": 4},
+		}), nil
+	})
+	recv := new(Recv)
+	err := NewBinder(WithBodyDecoder("application/x-tree", tree)).Bind(newBodyRequest("application/x-tree", ""), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"p|q": 1, "a#b": 2, "x@y": 3, "c.d": 4}, recv.M)
+}
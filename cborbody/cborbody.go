@@ -0,0 +1,27 @@
+// Package cborbody decodes CBOR request bodies for go-binding, json fields
+// read them with the same paths as a JSON body and take integers and byte
+// strings without converting them to strings. It is a separate module that
+// needs Go 1.20 or later.
+//
+//	b := binding.NewBinder(binding.WithBodyDecoders(cborbody.Decoder, cborbody.MediaTypes...))
+package cborbody
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	binding "github.com/kiancchen/go-binding"
+)
+
+// MediaTypes are the media types of CBOR bodies.
+var MediaTypes = []string{"application/cbor"}
+
+// Decoder decodes a CBOR body.
+var Decoder binding.BodyDecoder = binding.BodyDecoderFunc(decode)
+
+// decode 正整数解码为 uint64，负整数解码为 int64
+func decode(_ string, body []byte) (binding.Document, error) {
+	var tree interface{}
+	if err := cbor.Unmarshal(body, &tree); err != nil {
+		return nil, err
+	}
+	return binding.NewTreeDocument(tree), nil
+}
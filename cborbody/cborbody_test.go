@@ -0,0 +1,49 @@
+package cborbody
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	binding "github.com/kiancchen/go-binding"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBodyRequest(contentType, body string) binding.Request {
+	req, _ := http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return binding.WrapHTTPRequest(req)
+}
+
+func TestCBORBody(t *testing.T) {
+	type Req struct {
+		Id      int64  `bind:"id,json"`
+		Serial  uint64 `bind:"serial,json"`
+		Payload []byte `bind:"payload,json"`
+		Sensors []*struct {
+			Level int8 `bind:"level,json"`
+		} `bind:"sensors"`
+	}
+	data, err := cbor.Marshal(map[string]interface{}{
+		"id":      int64(math.MinInt64),
+		"serial":  uint64(math.MaxUint64),
+		"payload": []byte{0xff, 0x00, 0xfe},
+		"sensors": []interface{}{map[string]interface{}{"level": -1}},
+	})
+	assert.NoError(t, err)
+
+	recv := new(Req)
+	b := binding.NewBinder(binding.WithBodyDecoders(Decoder, MediaTypes...))
+	err = b.Bind(newBodyRequest("application/cbor", string(data)), recv)
+	assert.NoError(t, err)
+	// 整数不经过字符串转换，byte string 直接绑定到 []byte
+	assert.Equal(t, int64(math.MinInt64), recv.Id)
+	assert.Equal(t, uint64(math.MaxUint64), recv.Serial)
+	assert.Equal(t, []byte{0xff, 0x00, 0xfe}, recv.Payload)
+	assert.Equal(t, int8(-1), recv.Sensors[0].Level)
+
+	_, err = Decoder.Decode("application/cbor", data[:len(data)-1])
+	assert.Error(t, err)
+}
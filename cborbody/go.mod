@@ -0,0 +1,21 @@
+module github.com/kiancchen/go-binding/cborbody
+
+go 1.20
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.2
+	github.com/kiancchen/go-binding v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.7.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/tidwall/gjson v1.8.1 // indirect
+	github.com/tidwall/match v1.0.3 // indirect
+	github.com/tidwall/pretty v1.1.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+replace github.com/kiancchen/go-binding => ../
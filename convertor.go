@@ -1,14 +1,83 @@
 package binding
 
 import (
+	"encoding"
+	js "encoding/json"
 	"reflect"
 	"strconv"
+	"time"
 )
 
 type Convertor func(string) (interface{}, error)
 
+// Convert lets a Convertor be used as a FieldConvertor.
+func (c Convertor) Convert(_ *ConvertContext, originValue string) (interface{}, error) {
+	return c(originValue)
+}
+
+// ConvertContext describes the field a value is converted for.
+type ConvertContext struct {
+	// Field 字段的完整路径，如 Peoples.1.Name
+	Field string
+
+	// Type 目标类型，已解指针；slice 字段为元素的类型
+	Type reflect.Type
+
+	// Tag 字段的 struct tag
+	Tag reflect.StructTag
+
+	// Source 值的来源，如 query, header, default
+	Source string
+}
+
+// FieldConvertor converts a value knowing which field it fills.
+type FieldConvertor interface {
+	Convert(ctx *ConvertContext, originValue string) (interface{}, error)
+}
+
+// FieldConvertorFunc is a function used as a FieldConvertor.
+type FieldConvertorFunc func(ctx *ConvertContext, originValue string) (interface{}, error)
+
+func (f FieldConvertorFunc) Convert(ctx *ConvertContext, originValue string) (interface{}, error) {
+	return f(ctx, originValue)
+}
+
+// interfaceConvertor 注册给接口的 convertor，作用于所有实现了该接口的类型
+type interfaceConvertor struct {
+	iface     reflect.Type
+	convertor FieldConvertor
+}
+
+// format tag 中时间格式以外的选项
+const (
+	// formatUnix unix 时间戳，单位秒
+	formatUnix = "unix"
+	// formatUnixMs unix 时间戳，单位毫秒
+	formatUnixMs = "unixms"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*js.Unmarshaler)(nil)).Elem()
+)
+
 var (
-	convertMap     = map[reflect.Type]Convertor{}
+	// typeConvertMap 内置的类型 convertor，可以被注册的 convertor 覆盖
+	typeConvertMap = map[reflect.Type]FieldConvertor{
+		reflect.TypeOf(time.Duration(0)): Convertor(func(originValue string) (interface{}, error) {
+			return time.ParseDuration(originValue)
+		}),
+		timeType: FieldConvertorFunc(convertTime),
+	}
+
+	// formatConvertMap 字段设置了 format tag 时使用，优先于注册的 convertor
+	formatConvertMap = map[reflect.Type]FieldConvertor{
+		timeType: FieldConvertorFunc(convertTime),
+	}
+
+	// kindConvertMap 按 kind 兜底的 convertor
 	kindConvertMap = map[reflect.Kind]Convertor{
 		reflect.String: func(originValue string) (interface{}, error) {
 			return originValue, nil
@@ -119,27 +188,175 @@ var (
 	}
 )
 
-func RegisterTypeConvertor(target interface{}, convertor Convertor) {
+// RegisterTypeConvertor registers a convertor for the type of target.
+func (b *Binder) RegisterTypeConvertor(target interface{}, convertor Convertor) {
+	b.RegisterFieldConvertor(target, convertor)
+}
+
+// RegisterFieldConvertor registers a convertor for the type of target. If
+// target is a nil pointer to an interface, e.g. (*Enum)(nil), the convertor is
+// used for every type implementing the interface.
+func (b *Binder) RegisterFieldConvertor(target interface{}, convertor FieldConvertor) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	t := reflect.TypeOf(target)
-	convertMap[t] = convertor
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+		iface := t.Elem()
+		for i, ic := range b.interfaceConverts {
+			if ic.iface == iface {
+				b.interfaceConverts[i].convertor = convertor
+				return
+			}
+		}
+		b.interfaceConverts = append(b.interfaceConverts, interfaceConvertor{iface: iface, convertor: convertor})
+		return
+	}
+	b.convertMap[t] = convertor
 }
 
-func getConvertor(t reflect.Type) Convertor {
+// getFieldConvertor 在 getConvertor 的基础上考虑字段的 format tag 以及 json 原文
+func (b *Binder) getFieldConvertor(field *fieldMetadata, t reflect.Type, rawJSON bool) FieldConvertor {
+	if rawJSON {
+		return jsonUnmarshalerConvertor(t)
+	}
+
+	if field.format != "" {
+		if c, ok := formatConvertMap[t]; ok {
+			return c
+		}
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.getConvertor(t)
+}
+
+// getConvertor 查找 convertor 的顺序：
+//  1. 注册的类型
+//  2. 内置的类型，如 time.Duration, time.Time
+//  3. 注册给接口的 convertor
+//  4. 可以互相转换的注册类型，如 type Age int 使用注册给 int 的 convertor
+//  5. encoding.TextUnmarshaler
+//  6. kindConvertMap
+func (b *Binder) getConvertor(t reflect.Type) FieldConvertor {
 	if t == nil {
 		return nil
 	}
 
+	if c := b.getRegisteredConvertor(t); c != nil {
+		return c
+	}
+
+	if isTextUnmarshaler(t) {
+		return textUnmarshalerConvertor(t)
+	}
+
 	if c, ok := kindConvertMap[t.Kind()]; ok {
 		return c
 	}
 
-	if c, ok := convertMap[t]; ok {
+	return nil
+}
+
+// getRegisteredConvertor 查找注册的以及内置的类型 convertor，调用方需持有读锁
+func (b *Binder) getRegisteredConvertor(t reflect.Type) FieldConvertor {
+	if c, ok := b.convertMap[t]; ok {
 		return c
 	}
-	for tp, convertor := range convertMap {
-		if tp.ConvertibleTo(t) && t.ConvertibleTo(tp) && t.Kind() == tp.Kind() {
-			return convertor
+	if c, ok := typeConvertMap[t]; ok {
+		return c
+	}
+
+	for _, ic := range b.interfaceConverts {
+		if t.Implements(ic.iface) || reflect.PtrTo(t).Implements(ic.iface) {
+			return ic.convertor
 		}
 	}
-	return nil
+
+	// int, string 等预声明的类型不使用其他类型的 convertor，否则注册 time.Duration 后所有 int64 都会按 duration 转换
+	if isPredeclared(t) {
+		return nil
+	}
+	// 有多个可以转换的类型时优先使用预声明的类型，其次按类型名，结果与注册顺序无关
+	var found reflect.Type
+	for tp := range b.convertMap {
+		if tp.Kind() != t.Kind() || !tp.ConvertibleTo(t) || !t.ConvertibleTo(tp) {
+			continue
+		}
+		if found == nil || isPredeclared(tp) && !isPredeclared(found) ||
+			isPredeclared(tp) == isPredeclared(found) && tp.String() < found.String() {
+			found = tp
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return b.convertMap[found]
+}
+
+// isPredeclared 是否为 int, string 等预声明的类型
+func isPredeclared(t reflect.Type) bool {
+	return t.Name() != "" && t.PkgPath() == ""
+}
+
+func isTextUnmarshaler(t reflect.Type) bool {
+	return t.Kind() != reflect.Interface && reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+func isJSONUnmarshaler(t reflect.Type) bool {
+	return t.Kind() != reflect.Interface && reflect.PtrTo(t).Implements(jsonUnmarshalerType)
+}
+
+// isUnmarshaler 实现了 Unmarshaler 的类型作为一个整体转换，不再解析其中的 field 或元素
+func isUnmarshaler(t reflect.Type) bool {
+	return isTextUnmarshaler(t) || isJSONUnmarshaler(t)
+}
+
+// useJSONUnmarshaler 没有注册 convertor 时，json 中的值交给 json.Unmarshaler 处理
+func (b *Binder) useJSONUnmarshaler(t reflect.Type) bool {
+	if !isJSONUnmarshaler(t) {
+		return false
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.getRegisteredConvertor(t) == nil
+}
+
+func textUnmarshalerConvertor(t reflect.Type) Convertor {
+	return func(originValue string) (interface{}, error) {
+		ptr := reflect.New(t)
+		err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(originValue))
+		return ptr.Elem().Interface(), err
+	}
+}
+
+// jsonUnmarshalerConvertor 的参数是 json 原文
+func jsonUnmarshalerConvertor(t reflect.Type) Convertor {
+	return func(originValue string) (interface{}, error) {
+		ptr := reflect.New(t)
+		err := ptr.Interface().(js.Unmarshaler).UnmarshalJSON([]byte(originValue))
+		return ptr.Elem().Interface(), err
+	}
+}
+
+// convertTime 按 format tag 转换时间，format 可以是 unix, unixms 或者 time.Parse 的 layout
+func convertTime(ctx *ConvertContext, originValue string) (interface{}, error) {
+	format := ctx.Tag.Get(tagFormat)
+	switch format {
+	case "":
+		return time.Parse(time.RFC3339, originValue)
+	case formatUnix, formatUnixMs:
+		n, err := strconv.ParseInt(originValue, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if format == formatUnix {
+			return time.Unix(n, 0).UTC(), nil
+		}
+		return time.Unix(0, n*int64(time.Millisecond)).UTC(), nil
+	default:
+		return time.Parse(format, originValue)
+	}
 }
@@ -0,0 +1,272 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCode int
+
+type testName string
+
+type testRatio float64
+
+type testPair struct {
+	A, B string
+}
+
+type testPair2 testPair
+
+type testSwitch string
+
+func (s *testSwitch) UnmarshalText(text []byte) error {
+	*s = testSwitch("text " + string(text))
+	return nil
+}
+
+func TestConvertorPrecedence(t *testing.T) {
+	b := NewBinder(
+		WithTypeConvertor(testCode(0), func(s string) (interface{}, error) {
+			v, err := strconv.Atoi(s)
+			return v * 10, err
+		}),
+		WithTypeConvertor(testPair{}, func(s string) (interface{}, error) {
+			return testPair{A: s, B: s}, nil
+		}),
+		WithTypeConvertor(testSwitch(""), func(s string) (interface{}, error) {
+			return "registered " + s, nil
+		}),
+	)
+
+	type Recv struct {
+		Code     testCode       `bind:"code,query"`
+		CodePtr  *testCode      `bind:"code,query"`
+		Codes    []*testCode    `bind:"code,query"`
+		Int      int            `bind:"code,query"`
+		Name     testName       `bind:"name,query"`
+		Ratio    testRatio      `bind:"ratio,query"`
+		Pair     testPair       `bind:"pair,query"`
+		Pair2    *testPair2     `bind:"pair,query"`
+		Switch   testSwitch     `bind:"switch,query"`
+		Duration time.Duration  `bind:"duration,query"`
+		Timeout  *time.Duration `bind:"duration,query"`
+		Time     time.Time      `bind:"time,query"`
+		Times    []time.Time    `bind:"time,query"`
+		Int64    int64          `bind:"int64,query"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?code=1&name=n&ratio=0.5&pair=p&switch=on&duration=1m30s&time=2021-08-04T10:00:00Z&int64=90", nil)
+	recv := new(Recv)
+	err := b.Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+
+	// exact registration beats the kind default
+	assert.Equal(t, testCode(10), recv.Code)
+	assert.Equal(t, testCode(10), *recv.CodePtr)
+	assert.Equal(t, testCode(10), *recv.Codes[0])
+	assert.Equal(t, 1, recv.Int)
+	// unregistered named types fall back to their kind
+	assert.Equal(t, testRatio(0.5), recv.Ratio)
+	// convertible types use the registration of the other type
+	assert.Equal(t, testName("registered n"), recv.Name)
+	assert.Equal(t, testPair{"p", "p"}, recv.Pair)
+	assert.Equal(t, testPair2{"p", "p"}, *recv.Pair2)
+	// registration beats encoding.TextUnmarshaler
+	assert.Equal(t, testSwitch("registered on"), recv.Switch)
+	// built-in convertors
+	assert.Equal(t, 90*time.Second, recv.Duration)
+	assert.Equal(t, 90*time.Second, *recv.Timeout)
+	assert.Equal(t, time.Date(2021, 8, 4, 10, 0, 0, 0, time.UTC), recv.Time)
+	assert.Equal(t, []time.Time{time.Date(2021, 8, 4, 10, 0, 0, 0, time.UTC)}, recv.Times)
+	assert.Equal(t, int64(90), recv.Int64)
+}
+
+func TestConvertorConvertible(t *testing.T) {
+	b := NewBinder(
+		WithTypeConvertor(0, func(s string) (interface{}, error) {
+			v, err := strconv.Atoi(s)
+			return v * 10, err
+		}),
+		WithTypeConvertor(testName(""), func(s string) (interface{}, error) {
+			return "name " + s, nil
+		}),
+		WithTypeConvertor(time.Duration(0), func(s string) (interface{}, error) {
+			v, err := strconv.Atoi(s)
+			return time.Duration(v) * time.Second, err
+		}),
+	)
+
+	type Recv struct {
+		Int   int         `bind:"code,query"`
+		Code  testCode    `bind:"code,query"`
+		Codes []*testCode `bind:"code,query"`
+		Str   string      `bind:"name,query"`
+		Name  testName    `bind:"name,query"`
+		Int64 int64       `bind:"code,query"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?code=1&name=n", nil)
+	recv := new(Recv)
+	err := b.Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+
+	// a named type uses the convertor of its underlying type before the kind default
+	assert.Equal(t, 10, recv.Int)
+	assert.Equal(t, testCode(10), recv.Code)
+	assert.Equal(t, testCode(10), *recv.Codes[0])
+	assert.Equal(t, testName("name n"), recv.Name)
+	// predeclared types never borrow the convertor of a named type
+	assert.Equal(t, "n", recv.Str)
+	assert.Equal(t, int64(1), recv.Int64)
+}
+
+func TestConvertorOverrideBuiltin(t *testing.T) {
+	b := NewBinder()
+	b.RegisterTypeConvertor(time.Duration(0), func(s string) (interface{}, error) {
+		v, err := strconv.Atoi(s)
+		return time.Duration(v) * time.Second, err
+	})
+	b.RegisterTypeConvertor(time.Time{}, func(s string) (interface{}, error) {
+		return time.Parse("2006-01-02", s)
+	})
+
+	type Recv struct {
+		Duration time.Duration `bind:"duration,query"`
+		Time     time.Time     `bind:"time,query"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?duration=90&time=2021-08-04", nil)
+	recv := new(Recv)
+	err := b.Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, recv.Duration)
+	assert.Equal(t, time.Date(2021, 8, 4, 0, 0, 0, 0, time.UTC), recv.Time)
+}
+
+func TestConvertorWrongType(t *testing.T) {
+	b := NewBinder(WithTypeConvertor(testCode(0), func(s string) (interface{}, error) {
+		return s, nil
+	}))
+
+	type Recv struct {
+		Code testCode `bind:"code,query"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?code=1", nil)
+	recv := new(Recv)
+	err := b.Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.Equal(t, "parameter type cannot be converted from string: [code]", err.Error())
+	assert.Equal(t, testCode(0), recv.Code)
+}
+
+func TestTimeFormat(t *testing.T) {
+	b := NewBinder(WithTypeConvertor(time.Time{}, func(s string) (interface{}, error) {
+		return time.Parse("2006/01/02", s)
+	}))
+
+	type Recv struct {
+		Created    time.Time   `bind:"created,json" format:"2006-01-02T15:04:05Z07:00"`
+		Day        time.Time   `bind:"day,query" format:"2006-01-02"`
+		Days       []time.Time `bind:"day,query" format:"2006-01-02"`
+		Since      *time.Time  `bind:"X-Since,header" format:"unix"`
+		SinceMs    time.Time   `bind:"X-Since-Ms,header" format:"unixms"`
+		Registered time.Time   `bind:"registered,query"`
+		Bad        time.Time   `bind:"bad,query" format:"unix"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?day=2021-08-04&day=2021-08-05&registered=2021/08/04&bad=x",
+		strings.NewReader(`{"created":"2021-08-04T10:00:00+08:00"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Since", "1628071200")
+	req.Header.Set("X-Since-Ms", "1628071200123")
+	recv := new(Recv)
+	err := b.Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.Equal(t, "parameter type cannot be converted from string: [bad]", err.Error())
+
+	assert.True(t, time.Date(2021, 8, 4, 2, 0, 0, 0, time.UTC).Equal(recv.Created))
+	assert.Equal(t, time.Date(2021, 8, 4, 0, 0, 0, 0, time.UTC), recv.Day)
+	assert.Equal(t, []time.Time{time.Date(2021, 8, 4, 0, 0, 0, 0, time.UTC), time.Date(2021, 8, 5, 0, 0, 0, 0, time.UTC)}, recv.Days)
+	assert.Equal(t, time.Date(2021, 8, 4, 10, 0, 0, 0, time.UTC), *recv.Since)
+	assert.Equal(t, time.Date(2021, 8, 4, 10, 0, 0, 123000000, time.UTC), recv.SinceMs)
+	assert.Equal(t, time.Date(2021, 8, 4, 0, 0, 0, 0, time.UTC), recv.Registered)
+}
+
+type testEnum interface {
+	Values() []string
+}
+
+type testColor int
+
+func (testColor) Values() []string {
+	return []string{"red", "green"}
+}
+
+type testShape uint8
+
+func (*testShape) Values() []string {
+	return []string{"circle", "square"}
+}
+
+func TestFieldConvertor(t *testing.T) {
+	b := NewBinder()
+
+	// 所有实现了 testEnum 的类型按 Values 中的下标转换
+	b.RegisterFieldConvertor((*testEnum)(nil), FieldConvertorFunc(func(ctx *ConvertContext, s string) (interface{}, error) {
+		values := reflect.New(ctx.Type).Interface().(testEnum).Values()
+		for i, v := range values {
+			if v == s {
+				return reflect.ValueOf(i).Convert(ctx.Type).Interface(), nil
+			}
+		}
+		return nil, fmt.Errorf("%s: %q is not one of %v", ctx.Field, s, values)
+	}))
+
+	// 按 unit tag 转换大小
+	type size int64
+	var contexts []ConvertContext
+	b.RegisterFieldConvertor(size(0), FieldConvertorFunc(func(ctx *ConvertContext, s string) (interface{}, error) {
+		contexts = append(contexts, *ctx)
+		n, err := strconv.ParseInt(s, 10, 64)
+		if ctx.Tag.Get("unit") == "kb" {
+			n *= 1024
+		}
+		return size(n), err
+	}))
+
+	type Recv struct {
+		Color  testColor   `bind:"color,query"`
+		Shapes []testShape `bind:"shape,query"`
+		Bad    *testColor  `bind:"bad,query"`
+		Bytes  size        `bind:"X-Size,header"`
+		KB     *size       `bind:"kb,query" unit:"kb"`
+		Def    size        `bind:"def,query" default:"1" unit:"kb"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?color=green&shape=square&shape=circle&bad=blue&kb=2", nil)
+	req.Header.Set("X-Size", "10")
+	recv := new(Recv)
+	err := b.Bind(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.Equal(t, `parameter type cannot be converted from string: [bad]`, err.Error())
+	var fieldErr *Error
+	assert.True(t, errors.As(err, &fieldErr))
+	assert.Equal(t, `bad: "blue" is not one of [red green]`, fieldErr.Err.Error())
+
+	assert.Equal(t, testColor(1), recv.Color)
+	assert.Equal(t, []testShape{1, 0}, recv.Shapes)
+	assert.Equal(t, size(10), recv.Bytes)
+	assert.Equal(t, size(2048), *recv.KB)
+	assert.Equal(t, size(1024), recv.Def)
+
+	assert.Equal(t, 3, len(contexts))
+	assert.Equal(t, "X-Size", contexts[0].Field)
+	assert.Equal(t, "header", contexts[0].Source)
+	assert.Equal(t, reflect.TypeOf(size(0)), contexts[0].Type)
+	assert.Equal(t, "kb", contexts[1].Field)
+	assert.Equal(t, "query", contexts[1].Source)
+	assert.Equal(t, "default", contexts[2].Source)
+}
@@ -0,0 +1,290 @@
+package binding
+
+import (
+	js "encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Document is a request body decoded by a BodyDecoder, json fields look up
+// their values in it. A path is keys joined by dots like items.0.name, where
+// numbers index arrays and a backslash escapes one of .*?|#@\ inside a key.
+// The built-in documents also resolve paths with unescaped gjson syntax like
+// items.#.name.
+type Document interface {
+	// Get returns the value at path, ok is false if there is none.
+	Get(path string) (value DocumentValue, ok bool)
+
+	// Array returns the elements of the array at path. A value that isn't an
+	// array is returned as a single element, and nil if path doesn't exist.
+	Array(path string) []DocumentValue
+
+	// Keys returns the keys of the object at path, the empty path is the
+	// root. JSON keys are in document order.
+	Keys(path string) []string
+
+	// Exists reports whether path exists.
+	Exists(path string) bool
+}
+
+// ValueKind is the kind of a DocumentValue.
+type ValueKind int
+
+const (
+	ScalarValue ValueKind = iota
+	NullValue
+	ArrayValue
+	ObjectValue
+)
+
+// DocumentValue is a value of a Document.
+type DocumentValue struct {
+	Kind ValueKind
+
+	// Text is the value as a string. Strings are not quoted, objects and
+	// arrays are JSON text.
+	Text string
+
+	// Raw is the value as JSON text, used for types implementing
+	// json.Unmarshaler. Text is used instead when it's empty.
+	Raw string
+
+	// Typed is the decoded value for formats that keep types, e.g. int64,
+	// []byte or time.Time. A field of a matching type takes it without
+	// converting Text, nil means there is none.
+	Typed interface{}
+}
+
+// text 返回转换使用的 string 数据，rawJSON 时优先使用 json 原文
+func (v DocumentValue) text(rawJSON bool) string {
+	if rawJSON && v.Raw != "" {
+		return v.Raw
+	}
+	return v.Text
+}
+
+// BodyDecoder decodes a request body into a Document, see Binder.RegisterBodyDecoder.
+type BodyDecoder interface {
+	Decode(contentType string, body []byte) (Document, error)
+}
+
+// BodyDecoderFunc is a function used as a BodyDecoder.
+type BodyDecoderFunc func(contentType string, body []byte) (Document, error)
+
+func (f BodyDecoderFunc) Decode(contentType string, body []byte) (Document, error) {
+	return f(contentType, body)
+}
+
+// treeDocument 由 map[string]interface{}, []interface{} 以及标量组成的树，
+// yaml, msgpack 等格式解码后使用，其中的值保留解码得到的类型
+type treeDocument struct {
+	root interface{}
+
+	// json 含有 gjson 语法的路径使用的 json Document，第一次使用时由 root 编码得到
+	json    Document
+	jsonErr error
+}
+
+// NewTreeDocument returns a Document over a decoded tree of maps, slices
+// ([]interface{}) and scalars, like the result of unmarshalling into an
+// interface{}. Integers are kept as int64 or uint64 and []byte as is.
+func NewTreeDocument(tree interface{}) Document {
+	return &treeDocument{root: normalizeTree(tree)}
+}
+
+func (d *treeDocument) Get(path string) (DocumentValue, bool) {
+	if doc, ok := d.gjson(path); ok {
+		return doc.Get(path)
+	}
+	v, ok := d.lookup(path)
+	if !ok {
+		return DocumentValue{}, false
+	}
+	return treeValue(v), true
+}
+
+func (d *treeDocument) Array(path string) []DocumentValue {
+	if doc, ok := d.gjson(path); ok {
+		return doc.Array(path)
+	}
+	v, ok := d.lookup(path)
+	if !ok {
+		return nil
+	}
+	elems, ok := v.([]interface{})
+	if !ok {
+		return []DocumentValue{treeValue(v)}
+	}
+	values := make([]DocumentValue, len(elems))
+	for i, elem := range elems {
+		values[i] = treeValue(elem)
+	}
+	return values
+}
+
+func (d *treeDocument) Keys(path string) []string {
+	if doc, ok := d.gjson(path); ok {
+		return doc.Keys(path)
+	}
+	v, _ := d.lookup(path)
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	// map 没有顺序，按 key 排序
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (d *treeDocument) Exists(path string) bool {
+	if doc, ok := d.gjson(path); ok {
+		return doc.Exists(path)
+	}
+	_, ok := d.lookup(path)
+	return ok
+}
+
+// gjson 含有 gjson 语法的路径转为 json 后由 gjson 查找，结果中的值不保留类型
+func (d *treeDocument) gjson(path string) (Document, bool) {
+	if !hasGjsonSyntax(path) {
+		return nil, false
+	}
+	if d.json == nil && d.jsonErr == nil {
+		var raw []byte
+		if raw, d.jsonErr = js.Marshal(d.root); d.jsonErr == nil {
+			d.json, d.jsonErr = NewJSONDocument(raw)
+		}
+	}
+	if d.jsonErr != nil {
+		return emptyDocument{}, true
+	}
+	return d.json, true
+}
+
+// lookup 按路径逐段查找，空的路径为根节点
+func (d *treeDocument) lookup(path string) (interface{}, bool) {
+	node := d.root
+	if path == "" {
+		return node, true
+	}
+	for _, key := range splitPath(path) {
+		switch t := node.(type) {
+		case map[string]interface{}:
+			v, ok := t[key]
+			if !ok {
+				return nil, false
+			}
+			node = v
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, false
+			}
+			node = t[i]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// treeValue 转为 DocumentValue，Raw 为 json 编码，Text 与 json 中的值相同
+func treeValue(v interface{}) DocumentValue {
+	value := DocumentValue{Typed: v}
+	switch t := v.(type) {
+	case nil:
+		value.Kind = NullValue
+	case map[string]interface{}:
+		value.Kind = ObjectValue
+	case []interface{}:
+		value.Kind = ArrayValue
+	case byteString:
+		value.Typed = []byte(t)
+	}
+
+	raw, err := js.Marshal(v)
+	if err != nil {
+		// 如 NaN 等 json 无法表示的值
+		value.Text = fmt.Sprint(v)
+		return value
+	}
+	value.Raw = string(raw)
+	value.Text = value.Raw
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if js.Unmarshal(raw, &s) == nil {
+			value.Text = s
+		}
+	}
+	return value
+}
+
+// byteString 二进制 body 中的字节串，转为 json 时作为 string 而不是 base64
+type byteString []byte
+
+func (b byteString) MarshalText() ([]byte, error) {
+	return b, nil
+}
+
+// normalizeTree 把 key 不是 string 的 map 转为 map[string]interface{}，
+// 整数统一为 int64 或者 uint64，字节串转为 byteString
+func normalizeTree(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalizeTree(e)
+		}
+		return t
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[fmt.Sprint(k)] = normalizeTree(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeTree(e)
+		}
+		return t
+	case []map[string]interface{}:
+		s := make([]interface{}, len(t))
+		for i, e := range t {
+			s[i] = normalizeTree(e)
+		}
+		return s
+	case []byte:
+		return byteString(t)
+	case int:
+		return int64(t)
+	case int8:
+		return int64(t)
+	case int16:
+		return int64(t)
+	case int32:
+		return int64(t)
+	case uint:
+		return uint64(t)
+	case uint8:
+		return uint64(t)
+	case uint16:
+		return uint64(t)
+	case uint32:
+		return uint64(t)
+	case float32:
+		return float64(t)
+	}
+	return v
+}
+
+// emptyDocument 没有任何值的 Document
+type emptyDocument struct{}
+
+func (emptyDocument) Get(string) (DocumentValue, bool) { return DocumentValue{}, false }
+func (emptyDocument) Array(string) []DocumentValue     { return nil }
+func (emptyDocument) Keys(string) []string             { return nil }
+func (emptyDocument) Exists(string) bool               { return false }
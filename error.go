@@ -1,7 +1,9 @@
 package binding
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // Common Error
@@ -13,21 +15,124 @@ var (
 	FieldConversionError = &Error{
 		Format: "go-binding error: field=%s, cause=%s",
 		Cause:  "field type can't be converted from string"}
+	FieldPreprocessError = &Error{
+		Format: "go-binding error: field=%s, cause=%s",
+		Cause:  "field preprocessor failed"}
+	FieldValidationError = &Error{
+		Format: "go-binding error: field=%s, cause=%s",
+		Cause:  "field validation failed"}
+	FieldAmbiguousError = &Error{
+		Format: "go-binding error: field=%s, cause=%s",
+		Cause:  "field matches several keys that differ only by case"}
+	FieldNullError = &Error{
+		Format: "go-binding error: field=%s, cause=%s",
+		Cause:  "field can't be null"}
+	FieldUnknownError = &Error{
+		Format: "go-binding error: field=%s, cause=%s",
+		Cause:  "parameter is not used by any field"}
+
+	// body 的错误只交给 WithBodyWarn，Field 为请求的 Content-Type
+	BodyDecodeError = &Error{
+		Format: "go-binding error: content-type=%s, cause=%s",
+		Cause:  "body can't be decoded"}
+	BodyUnsupportedError = &Error{
+		Format: "go-binding error: content-type=%s, cause=%s",
+		Cause:  "no body decoder registered for the media type"}
 )
 
+// Error describes a field that failed to bind. The errors returned by Bind
+// can be matched against the common errors above with errors.Is.
 type Error struct {
-	field string
+	// Field 字段的完整路径，如 Peoples.1.Name
+	Field string
+
+	// Source 获取值的来源，如 query, header；未找到时为尝试过的来源
+	Source string
+
+	// Value 获取到的原始值
+	Value string
+
+	// Err 底层的错误
+	Err error
 
 	Format string
 	Cause  string
 }
 
-func (e *Error) setField(fieldName string) *Error {
-	err := *e
-	err.field = fieldName
-	return &err
+// with 复制一份 e 并填入字段信息
+func (e *Error) with(field, source, value string, err error) *Error {
+	clone := *e
+	clone.Field = field
+	clone.Source = source
+	clone.Value = value
+	clone.Err = err
+	return &clone
 }
 
 func (e *Error) Error() string {
-	return fmt.Sprintf(e.Format, e.field, e.Cause)
+	msg := fmt.Sprintf(e.Format, e.Field, e.Cause)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an Error of the same kind, e.g. FieldNotFound.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Cause == e.Cause && t.Format == e.Format
+}
+
+// BindErrors is returned by Bind and lists every field that failed to bind.
+type BindErrors []*Error
+
+func (es BindErrors) Error() string {
+	notFound := make([]string, 0)
+	conversion := make([]string, 0)
+	others := make([]string, 0)
+	for _, e := range es {
+		switch {
+		case e.Is(FieldNotFound):
+			notFound = append(notFound, e.Field)
+		case e.Is(FieldConversionError):
+			conversion = append(conversion, e.Field)
+		default:
+			others = append(others, e.Error())
+		}
+	}
+
+	msgs := make([]string, 0, len(others)+2)
+	if len(notFound) != 0 {
+		msgs = append(msgs, fmt.Sprintf("parameter required but not found: [%v]", strings.Join(notFound, ", ")))
+	}
+	if len(conversion) != 0 {
+		msgs = append(msgs, fmt.Sprintf("parameter type cannot be converted from string: [%v]", strings.Join(conversion, ", ")))
+	}
+	msgs = append(msgs, others...)
+
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the field errors matches target.
+func (es BindErrors) Is(target error) bool {
+	for _, e := range es {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first field error that matches target.
+func (es BindErrors) As(target interface{}) bool {
+	for _, e := range es {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,184 @@
+package binding
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// jsonNode 解析后的 json 节点，对象和数组的子节点在第一次访问时建立索引，
+// 每个字段从根节点按路径逐段查找，不再每次扫描整个 body
+type jsonNode struct {
+	result gjson.Result
+
+	indexed  bool
+	children map[string]*jsonNode
+	// keys 对象的 key，按在 body 中的顺序
+	keys  []string
+	elems []*jsonNode
+}
+
+// jsonDocument 默认的 json Document
+type jsonDocument struct {
+	root *jsonNode
+}
+
+// NewJSONDocument parses body as a JSON Document. It is what the built-in
+// JSON decoder returns, and decoders of formats that can be turned into JSON
+// can use it too.
+func NewJSONDocument(body []byte) (Document, error) {
+	if !gjson.ValidBytes(body) {
+		return nil, errors.New("invalid json")
+	}
+	return &jsonDocument{root: &jsonNode{result: gjson.ParseBytes(body)}}, nil
+}
+
+func (d *jsonDocument) Get(path string) (DocumentValue, bool) {
+	n := d.root.get(path)
+	if n == nil {
+		return DocumentValue{}, false
+	}
+	return n.value(), true
+}
+
+func (d *jsonDocument) Array(path string) []DocumentValue {
+	n := d.root.get(path)
+	if n == nil {
+		return nil
+	}
+	if !n.result.IsArray() {
+		return []DocumentValue{n.value()}
+	}
+	if !n.indexed {
+		n.index()
+	}
+	values := make([]DocumentValue, len(n.elems))
+	for i, elem := range n.elems {
+		values[i] = elem.value()
+	}
+	return values
+}
+
+func (d *jsonDocument) Keys(path string) []string {
+	n := d.root.get(path)
+	if n == nil {
+		return nil
+	}
+	if !n.indexed {
+		n.index()
+	}
+	return n.keys
+}
+
+func (d *jsonDocument) Exists(path string) bool {
+	return d.root.get(path) != nil
+}
+
+func (n *jsonNode) value() DocumentValue {
+	v := DocumentValue{Text: n.result.String(), Raw: n.result.Raw}
+	switch {
+	case n.result.Type == gjson.Null:
+		v.Kind = NullValue
+	case n.result.IsArray():
+		v.Kind = ArrayValue
+	case n.result.IsObject():
+		v.Kind = ObjectValue
+	}
+	return v
+}
+
+func (n *jsonNode) index() {
+	n.indexed = true
+	if n.result.IsArray() {
+		n.result.ForEach(func(_, v gjson.Result) bool {
+			n.elems = append(n.elems, &jsonNode{result: v})
+			return true
+		})
+	} else if n.result.IsObject() {
+		n.children = make(map[string]*jsonNode)
+		n.result.ForEach(func(k, v gjson.Result) bool {
+			// 与 gjson 相同，重复的 key 使用第一个
+			if _, ok := n.children[k.String()]; !ok {
+				n.children[k.String()] = &jsonNode{result: v}
+				n.keys = append(n.keys, k.String())
+			}
+			return true
+		})
+	}
+}
+
+func (n *jsonNode) child(key string) *jsonNode {
+	if !n.indexed {
+		n.index()
+	}
+	if n.children != nil {
+		return n.children[key]
+	}
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || i >= len(n.elems) {
+		return nil
+	}
+	return n.elems[i]
+}
+
+// gjsonSyntax gjson 路径中的特殊字符
+const gjsonSyntax = "#*?|@"
+
+// hasGjsonSyntax 路径中是否有没有转义的 gjson 语法字符
+func hasGjsonSyntax(path string) bool {
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == '\\':
+			i++
+		case strings.IndexByte(gjsonSyntax, c) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+// get 按路径获取节点，如 Peoples.1.Name，\. 表示 key 中的 .，空的路径为根节点。
+// 含有没有转义的 gjson 语法的路径交给 gjson 查找，如 items.#.name
+func (n *jsonNode) get(path string) *jsonNode {
+	if path == "" {
+		return n
+	}
+	if hasGjsonSyntax(path) {
+		result := n.result.Get(path)
+		if !result.Exists() {
+			return nil
+		}
+		return &jsonNode{result: result}
+	}
+	node := n
+	for _, key := range splitPath(path) {
+		if node = node.child(key); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// splitPath 按没有转义的 . 拆分路径
+func splitPath(path string) []string {
+	if !strings.Contains(path, `\`) {
+		return strings.Split(path, ".")
+	}
+	var keys []string
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == '\\' && i+1 < len(path):
+			i++
+			sb.WriteByte(path[i])
+		case c == '.':
+			keys = append(keys, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return append(keys, sb.String())
+}
@@ -0,0 +1,218 @@
+package binding
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// mapEntry map 中一个 key 的原始数据
+type mapEntry struct {
+	key    string
+	values []string
+
+	// json 中的值是否为对象，value 为 struct 时使用
+	isObject bool
+}
+
+// bindMap 从 json 对象、name[key] 形式或者以 prefix tag 开头的 query, form key
+// 以及 header 中绑定 map，使用第一个有值的来源
+func (b *Binder) bindMap(r *request, fieldMeta *fieldMetadata) (value reflect.Value) {
+	for _, source := range b.fieldSources(fieldMeta) {
+		entries, ok := b.lookupMap(r, fieldMeta, source)
+		if !ok {
+			continue
+		}
+		fieldMeta.valueSource = sourceNames[source]
+		if fieldMeta.isNull {
+			fieldMeta.isUnset = false
+			return
+		}
+		value = b.makeMap(r, fieldMeta, source, entries)
+		fieldMeta.isUnset = false
+		fieldMeta.hasValue = true
+		return
+	}
+
+	fieldMeta.isUnset = true
+	return
+}
+
+// lookupMap 从一个来源获取 map 的原始数据
+func (b *Binder) lookupMap(r *request, fieldMeta *fieldMetadata, source int) (entries []mapEntry, present bool) {
+	mapMeta := fieldMeta.mapMeta
+	switch source {
+	case header:
+		// auto 时只有设置了 prefix 才从 header 获取，避免绑定所有 header
+		if fieldMeta.source == auto && fieldMeta.mapPrefix == "" {
+			return nil, false
+		}
+		prefix := http.CanonicalHeaderKey(fieldMeta.mapPrefix)
+		for _, key := range sortedKeys(r.header) {
+			if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+				entries = append(entries, mapEntry{key: key[len(prefix):], values: r.header[key]})
+			}
+		}
+	case query:
+		entries = prefixedEntries(r.query, fieldMeta.nameOf(query), fieldMeta.mapPrefix)
+	case form:
+		entries = prefixedEntries(r.postForm, fieldMeta.nameOf(form), fieldMeta.mapPrefix)
+	case json:
+		path := b.jsonPath(r, fieldMeta, fieldMeta.fieldJsonName)
+		v, ok := r.GetDocument(path)
+		if ok && v.Kind == NullValue {
+			fieldMeta.isNull = true
+			return nil, true
+		}
+		if v.Kind != ObjectValue {
+			return nil, false
+		}
+		rawJSON := b.useJSONUnmarshaler(mapMeta.elemType)
+		for _, key := range r.doc.Keys(path) {
+			keyPath := path + "." + escapePathKey(key)
+			elem, _ := r.doc.Get(keyPath)
+			entry := mapEntry{key: key, isObject: elem.Kind == ObjectValue}
+			elems := []DocumentValue{elem}
+			if mapMeta.isSlice && elem.Kind == ArrayValue {
+				elems = r.doc.Array(keyPath)
+			}
+			for _, e := range elems {
+				entry.values = append(entry.values, e.text(rawJSON))
+			}
+			entries = append(entries, entry)
+		}
+		return entries, true
+	}
+	return entries, len(entries) != 0
+}
+
+// prefixedEntries 获取 name[key] 形式的 key，设置了 prefix 时获取以 prefix 开头的 key
+func prefixedEntries(values map[string][]string, name, prefix string) (entries []mapEntry) {
+	for _, key := range sortedKeys(values) {
+		var k string
+		if prefix != "" {
+			if !strings.HasPrefix(key, prefix) || len(key) == len(prefix) {
+				continue
+			}
+			k = key[len(prefix):]
+		} else {
+			if !strings.HasPrefix(key, name+"[") || !strings.HasSuffix(key, "]") {
+				continue
+			}
+			k = key[len(name)+1 : len(key)-1]
+		}
+		entries = append(entries, mapEntry{key: k, values: values[key]})
+	}
+	return
+}
+
+// makeMap 转换每个 key 和 value，value 为 struct 时与 struct slice 一样绑定
+func (b *Binder) makeMap(r *request, fieldMeta *fieldMetadata, source int, entries []mapEntry) reflect.Value {
+	mapMeta := fieldMeta.mapMeta
+	value := reflect.MakeMapWithSize(mapMeta.mapType, len(entries))
+	mapMeta.structData = nil
+	mapMeta.keys = nil
+
+	keyConvertor := b.getFieldConvertor(fieldMeta, mapMeta.keyType, false)
+	elemConvertor := b.getFieldConvertor(fieldMeta, mapMeta.elemType, source == json && b.useJSONUnmarshaler(mapMeta.elemType))
+	for _, entry := range entries {
+		path := fieldMeta.valuePath() + "." + escapePathKey(entry.key)
+		ctx := &ConvertContext{
+			Field:  path,
+			Type:   mapMeta.keyType,
+			Tag:    fieldMeta.tagInfo,
+			Source: fieldMeta.valueSource,
+		}
+		key, ok := b.convertMapValue(fieldMeta, keyConvertor, ctx, entry.key)
+		if !ok {
+			continue
+		}
+
+		var elem reflect.Value
+		if mapMeta.isStruct {
+			if !entry.isObject {
+				continue
+			}
+			sd := mapMeta.structMeta.clone()
+			sd.attachLayer(escapePathKey(entry.key))
+			receiver := reflect.New(mapMeta.elemType)
+			b.bindStruct(r, receiver, sd)
+			if !mapMeta.isPtr {
+				receiver = receiver.Elem()
+			}
+			mapMeta.structData = append(mapMeta.structData, sd)
+			mapMeta.keys = append(mapMeta.keys, entry.key)
+			elem = receiver
+		} else {
+			ctx.Type = mapMeta.elemType
+			if mapMeta.isSlice {
+				elem = reflect.MakeSlice(mapMeta.mapType.Elem(), 0, len(entry.values))
+				for _, v := range entry.values {
+					e, ok := b.convertMapValue(fieldMeta, elemConvertor, ctx, v)
+					if !ok {
+						continue
+					}
+					if mapMeta.isPtr {
+						ptr := reflect.New(mapMeta.elemType)
+						ptr.Elem().Set(e)
+						e = ptr
+					}
+					elem = reflect.Append(elem, e)
+				}
+			} else {
+				if len(entry.values) == 0 {
+					continue
+				}
+				e, ok := b.convertMapValue(fieldMeta, elemConvertor, ctx, entry.values[0])
+				if !ok {
+					continue
+				}
+				elem = e
+				if mapMeta.isPtr {
+					elem = reflect.New(mapMeta.elemType)
+					elem.Elem().Set(e)
+				}
+			}
+		}
+		value.SetMapIndex(key, elem)
+	}
+	return value
+}
+
+// convertMapValue 转换 map 的一个 key 或者 value，失败时记录转换错误
+func (b *Binder) convertMapValue(fieldMeta *fieldMetadata, convertor FieldConvertor, ctx *ConvertContext, s string) (reflect.Value, bool) {
+	var err error
+	var v reflect.Value
+	if convertor == nil {
+		err = fmt.Errorf("no convertor for type %v", ctx.Type)
+	} else {
+		var converted interface{}
+		converted, err = convertor.Convert(ctx, s)
+		v = reflect.ValueOf(converted)
+		if v.IsValid() && !v.Type().ConvertibleTo(ctx.Type) {
+			err = fmt.Errorf("convertor returned %v instead of %v", v.Type(), ctx.Type)
+		}
+	}
+	if err != nil {
+		fieldMeta.errs = append(fieldMeta.errs, FieldConversionError.with(ctx.Field, ctx.Source, s, err))
+		return v, false
+	}
+	if !v.IsValid() {
+		return reflect.Zero(ctx.Type), true
+	}
+	return v.Convert(ctx.Type), true
+}
+
+// escapePathKey 转义 map key 中路径的特殊字符
+func escapePathKey(key string) string {
+	var sb strings.Builder
+	for _, c := range key {
+		switch c {
+		case '.', '*', '?', '|', '#', '@', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
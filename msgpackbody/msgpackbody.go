@@ -0,0 +1,33 @@
+// Package msgpackbody decodes MessagePack request bodies for go-binding, json
+// fields read them with the same paths as a JSON body and take integers, bin
+// and timestamps without converting them to strings. It is a separate module
+// that needs Go 1.19 or later.
+//
+//	b := binding.NewBinder(binding.WithBodyDecoders(msgpackbody.Decoder, msgpackbody.MediaTypes...))
+package msgpackbody
+
+import (
+	"bytes"
+
+	binding "github.com/kiancchen/go-binding"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MediaTypes are the media types of MessagePack bodies.
+var MediaTypes = []string{"application/msgpack", "application/x-msgpack"}
+
+// Decoder decodes a MessagePack body.
+var Decoder binding.BodyDecoder = binding.BodyDecoderFunc(decode)
+
+// decode bin 解码为 []byte，map 的 key 可以不是 string
+func decode(_ string, body []byte) (binding.Document, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(body))
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+	tree, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return binding.NewTreeDocument(tree), nil
+}
@@ -0,0 +1,51 @@
+package msgpackbody
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	binding "github.com/kiancchen/go-binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func newBodyRequest(contentType, body string) binding.Request {
+	req, _ := http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return binding.WrapHTTPRequest(req)
+}
+
+func TestMsgpackBody(t *testing.T) {
+	type Req struct {
+		Id      int64     `bind:"id,json"`
+		Serial  uint64    `bind:"serial,json"`
+		Payload []byte    `bind:"payload,json"`
+		Seen    time.Time `bind:"seen,json"`
+	}
+	seen := time.Date(2021, 8, 4, 10, 0, 0, 5, time.UTC)
+	data, err := msgpack.Marshal(map[string]interface{}{
+		"id":      int64(math.MinInt64),
+		"serial":  uint64(math.MaxUint64),
+		"payload": []byte{0xff, 0x00, 0xfe},
+		"seen":    seen,
+	})
+	assert.NoError(t, err)
+
+	b := binding.NewBinder(binding.WithBodyDecoders(Decoder, MediaTypes...))
+	for _, mediaType := range MediaTypes {
+		recv := new(Req)
+		err = b.Bind(newBodyRequest(mediaType, string(data)), recv)
+		assert.NoError(t, err)
+		// 整数不经过字符串转换，bin 直接绑定到 []byte
+		assert.Equal(t, int64(math.MinInt64), recv.Id)
+		assert.Equal(t, uint64(math.MaxUint64), recv.Serial)
+		assert.Equal(t, []byte{0xff, 0x00, 0xfe}, recv.Payload)
+		assert.True(t, seen.Equal(recv.Seen))
+	}
+
+	_, err = Decoder.Decode("application/msgpack", data[:len(data)-1])
+	assert.Error(t, err)
+}
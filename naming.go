@@ -0,0 +1,76 @@
+package binding
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy maps a Go field name to the name used in a source, see
+// WithNamingStrategy.
+type NamingStrategy func(name string) string
+
+// SnakeCase maps UserID to user_id.
+func SnakeCase(name string) string {
+	return joinWords(splitWords(name), "_", strings.ToLower)
+}
+
+// KebabCase maps UserID to user-id.
+func KebabCase(name string) string {
+	return joinWords(splitWords(name), "-", strings.ToLower)
+}
+
+// CamelCase maps UserID to userId.
+func CamelCase(name string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToLower(words[0]) + joinWords(words[1:], "", titleWord)
+}
+
+// HeaderCase maps XRequestID to X-Request-Id.
+func HeaderCase(name string) string {
+	return joinWords(splitWords(name), "-", titleWord)
+}
+
+func joinWords(words []string, sep string, f func(string) string) string {
+	for i, w := range words {
+		words[i] = f(w)
+	}
+	return strings.Join(words, sep)
+}
+
+func titleWord(w string) string {
+	r := []rune(strings.ToLower(w))
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// splitWords 按大小写和 _ - 拆分单词，连续的大写字母作为一个单词，如 HTTPServerID 拆为 HTTP Server ID
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i, r := range runes {
+		if r == '_' || r == '-' {
+			if start < i {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(r) {
+			continue
+		}
+		prev := runes[i-1]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,63 @@
+package binding
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamingStrategy(t *testing.T) {
+	assert.Equal(t, "user_id", SnakeCase("UserID"))
+	assert.Equal(t, "http_server_id", SnakeCase("HTTPServerID"))
+	assert.Equal(t, "a2", SnakeCase("A2"))
+	assert.Equal(t, "page_size", SnakeCase("page_size"))
+	assert.Equal(t, "user-id", KebabCase("UserID"))
+	assert.Equal(t, "userId", CamelCase("UserID"))
+	assert.Equal(t, "httpServerId", CamelCase("HTTPServerID"))
+	assert.Equal(t, "X-Request-Id", HeaderCase("XRequestID"))
+	assert.Equal(t, "", CamelCase(""))
+}
+
+func TestBindNamingStrategy(t *testing.T) {
+	b := NewBinder(
+		WithNamingStrategy(SnakeCase, "query", "form"),
+		WithNamingStrategy(CamelCase, "json"),
+		WithNamingStrategy(HeaderCase, "header"),
+		WithJSONTag(),
+	)
+
+	type Inner struct {
+		ItemName string
+	}
+	type Recv struct {
+		PageSize   int
+		UserName   string
+		XRequestID string `bind:"header"`
+		Explicit   string `bind:"Explicit_Name,query"`
+		PerSource  string `bind:"query" query:"per"`
+		JsonTag    string `json:"json_tag"`
+		Inner      Inner
+		Items      []Inner
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?page_size=10&Explicit_Name=e&per=p&PerSource=x",
+		strings.NewReader(`{"userName":"u","json_tag":"j","inner":{"itemName":"i"},"items":[{"itemName":"a"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "r")
+	recv := new(Recv)
+	err := b.Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, recv.PageSize)
+	assert.Equal(t, "u", recv.UserName)
+	assert.Equal(t, "r", recv.XRequestID)
+	assert.Equal(t, "e", recv.Explicit)
+	assert.Equal(t, "p", recv.PerSource)
+	assert.Equal(t, "j", recv.JsonTag)
+	assert.Equal(t, "i", recv.Inner.ItemName)
+	assert.Equal(t, "a", recv.Items[0].ItemName)
+
+	assert.Panics(t, func() {
+		NewBinder(WithNamingStrategy(SnakeCase, "body"))
+	})
+}
@@ -11,10 +11,13 @@ import (
 
 const (
 	// split tag的key，value用英文逗号分隔
-	split      = ","
-	tagBind    = "bind"
-	tagDefault = "default"
-	tagPre     = "pre"
+	split       = ","
+	tagBind     = "bind"
+	tagDefault  = "default"
+	tagPre      = "pre"
+	tagValidate = "validate"
+	tagFormat   = "format"
+	tagPrefix   = "prefix"
 
 	// 参数来源
 	header = 1 << 0
@@ -22,6 +25,8 @@ const (
 	form   = 1 << 2
 	path   = 1 << 3
 	json   = 1 << 4
+	cookie = 1 << 5
+	xml    = 1 << 6
 	auto   = math.MaxInt32
 
 	// tagBind 的选项
@@ -32,8 +37,15 @@ const (
 	bindForm     = "form"
 	bindPath     = "path"
 	bindJson     = "json"
+	bindCookie   = "cookie"
+	bindXml      = "xml"
 	bindRequired = "required"
 	bindReq      = "req"
+	bindOrdered  = "ordered"
+	bindMerge    = "merge"
+	bindUnique   = "unique"
+	bindNoCase   = "nocase"
+	bindNotNull  = "notnull"
 )
 
 var sourceMap = map[string]int{
@@ -43,6 +55,35 @@ var sourceMap = map[string]int{
 	bindForm:   form,
 	bindPath:   path,
 	bindJson:   json,
+	bindCookie: cookie,
+	bindXml:    xml,
+}
+
+// sourceOrder 指定多个来源时获取值的顺序
+var sourceOrder = []int{header, cookie, query, path, form, json, xml}
+
+var sourceNames = map[int]string{
+	header: bindHeader,
+	cookie: bindCookie,
+	query:  bindQuery,
+	path:   bindPath,
+	form:   bindForm,
+	json:   bindJson,
+	xml:    bindXml,
+}
+
+// sourceName 返回来源的名字，多个来源用英文逗号分隔
+func sourceName(source int) string {
+	if source == auto {
+		return bindAuto
+	}
+	names := make([]string, 0, len(sourceOrder))
+	for _, s := range sourceOrder {
+		if hasTag(source, s) {
+			names = append(names, sourceNames[s])
+		}
+	}
+	return strings.Join(names, split)
 }
 
 var fileType = reflect.TypeOf(multipart.FileHeader{})
@@ -66,20 +107,46 @@ type StructMetadata struct {
 }
 
 func (s *StructMetadata) attachLayerNum(num int) {
+	s.attachLayer(strconv.Itoa(num))
+}
+
+// attachLayer 把路径中第一个 # 替换为 slice 的下标或者 map 的 key
+func (s *StructMetadata) attachLayer(layer string) {
 	for i := range s.FieldList {
 		f := s.FieldList[i]
-		f.fieldJsonName = strings.Replace(f.fieldJsonName, "#", strconv.Itoa(num), 1)
-		f.parentSliceIdx = num
+		f.fieldJsonName = replaceLayer(f.fieldJsonName, layer)
+		f.fieldXmlName = replaceLayer(f.fieldXmlName, layer)
 		if f.isSlice && f.sliceMeta.isStruct {
-			f.sliceMeta.fieldJsonName = strings.Replace(f.sliceMeta.fieldJsonName, "#", strconv.Itoa(num), 1)
-			f.sliceMeta.structMeta.attachLayerNum(num)
+			f.sliceMeta.fieldJsonName = replaceLayer(f.sliceMeta.fieldJsonName, layer)
+			f.sliceMeta.fieldXmlName = replaceLayer(f.sliceMeta.fieldXmlName, layer)
+			f.sliceMeta.structMeta.attachLayer(layer)
 			for _, sd := range f.sliceMeta.structData {
-				sd.attachLayerNum(num)
+				sd.attachLayer(layer)
+			}
+		} else if f.isMap && f.mapMeta.isStruct {
+			f.mapMeta.fieldJsonName = replaceLayer(f.mapMeta.fieldJsonName, layer)
+			f.mapMeta.fieldXmlName = replaceLayer(f.mapMeta.fieldXmlName, layer)
+			f.mapMeta.structMeta.attachLayer(layer)
+			for _, sd := range f.mapMeta.structData {
+				sd.attachLayer(layer)
 			}
 		} else if f.isStruct {
-			f.structMeta.attachLayerNum(num)
+			f.structMeta.attachLayer(layer)
+		}
+	}
+}
+
+// replaceLayer 把路径中第一个没有转义的 # 替换为 layer，map 的 key 中转义后的 \# 不替换
+func replaceLayer(path, layer string) string {
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '#':
+			return path[:i] + layer + path[i+1:]
 		}
 	}
+	return path
 }
 
 func (s *StructMetadata) clone() *StructMetadata {
@@ -115,6 +182,9 @@ type sliceMetadata struct {
 
 	// 用来在 json 中查询的名字
 	fieldJsonName string
+
+	// 用来在 xml 中查询的名字
+	fieldXmlName string
 }
 
 func (s *sliceMetadata) clone() *sliceMetadata {
@@ -130,6 +200,52 @@ func (s *sliceMetadata) clone() *sliceMetadata {
 	return &clone
 }
 
+type mapMetadata struct {
+	// map 原始类型 map[string]*struct
+	mapType reflect.Type
+
+	// key 的类型
+	keyType reflect.Type
+
+	// value 是否是 slice，如 map[string][]string
+	isSlice bool
+
+	// 解 slice 和指针后 value 的类型 struct
+	elemType reflect.Type
+
+	// value 或者 value 中 slice 的元素是否是指针
+	isPtr bool
+
+	// value 是否是 struct
+	isStruct bool
+
+	// 如果是 struct, elemType 的信息
+	structMeta *StructMetadata
+
+	// 绑定后每个 key 的信息，与 keys 一一对应
+	structData []*StructMetadata
+	keys       []string
+
+	// 用来在 json 中查询的名字
+	fieldJsonName string
+
+	// 用来在 xml 中查询的名字
+	fieldXmlName string
+}
+
+func (m *mapMetadata) clone() *mapMetadata {
+	clone := *m
+	if m.isStruct {
+		clone.structMeta = m.structMeta.clone()
+		clone.structData = make([]*StructMetadata, len(m.structData))
+		for i, data := range m.structData {
+			clone.structData[i] = data.clone()
+		}
+	}
+
+	return &clone
+}
+
 // Field的结构化信息
 type fieldMetadata struct {
 	isIgnored bool
@@ -160,6 +276,15 @@ type fieldMetadata struct {
 	// elemType 的信息
 	sliceMeta *sliceMetadata
 
+	// 是否是 Map
+	isMap bool
+
+	// elemType 的信息
+	mapMeta *mapMetadata
+
+	// prefix tag，map 从以此开头的 query, form, header key 中获取值
+	mapPrefix string
+
 	isExported bool
 
 	// Tag原始信息
@@ -171,14 +296,36 @@ type fieldMetadata struct {
 	// Field的名字，用于从Json中找值
 	fieldJsonName string
 
-	parentSliceIdx int
+	// Field在Xml中的路径，只使用 bind tag 中的名字和 xml 的 naming strategy，不受 json tag 影响
+	fieldXmlName string
 
-	// Field来源，Query,Body,Header
+	// 通过 query, header 等 tag 为单个来源指定的名字
+	sourceFieldNames map[int]string
+
+	// Field来源，Query,Body,Header,Cookie
 	source int
 
+	// bind tag 中来源的书写顺序
+	sourceList []int
+
+	// 是否按 bind tag 中的书写顺序获取值
+	isOrdered bool
+
+	// slice 是否合并所有来源的值
+	isMerge bool
+
+	// slice 是否去掉重复的值
+	isUnique bool
+
+	// query, form, json 的 key 是否不区分大小写
+	isNoCase bool
+
 	// 是否是必传的参数
 	isRequired bool
 
+	// json 中的值是否不能为 null
+	isNotNull bool
+
 	// 有没有设置default值
 	hasDefault bool
 
@@ -187,23 +334,45 @@ type fieldMetadata struct {
 	// default值
 	defaultVal string
 
+	// format tag，如时间的格式
+	format string
+
+	// validate tag 中的规则
+	validators []validator
+
+	// validate tag 中 dive 之后的规则，作用于 slice 的每个元素
+	elemValidators []validator
+
 	value *reflect.Value
 
+	// 从 json 中获取值时使用 json 原文，交给 json.Unmarshaler 处理
+	rawJSON bool
+
+	// 实际获取到值的来源
+	valueSource string
+
+	// 从 yaml, msgpack 等解码后的 body 中获取到的值，保留解码得到的类型
+	bodyValue interface{}
+
+	// json 中的值是否为 null
+	isNull bool
+
 	hasValue bool
 
 	isUnset bool
 
-	hasConversionError bool
+	// 类型转换的错误，nil 表示没有错误
+	conversionErr *Error
 
-	errs []error
+	errs []*Error
 }
 
 func (field *fieldMetadata) setValue(recv reflect.Value) {
 	if field.hasValue {
 		v := *field.value
 		if field.isPtr {
-			ptr := reflect.New(v.Type())
-			ptr.Elem().Set(v)
+			ptr := reflect.New(field.elemType)
+			ptr.Elem().Set(v.Convert(field.elemType))
 			recv.Set(ptr)
 		} else {
 			recv.Set(v.Convert(field.elemType))
@@ -224,10 +393,14 @@ func (field *fieldMetadata) clone() *fieldMetadata {
 		clone.sliceMeta = field.sliceMeta.clone()
 	}
 
+	if field.mapMeta != nil {
+		clone.mapMeta = field.mapMeta.clone()
+	}
+
 	return &clone
 }
 
-func (field *fieldMetadata) parseTag() {
+func (field *fieldMetadata) parseTag(b *Binder) {
 	tagInfo := field.tagInfo
 
 	// parse default tag
@@ -237,6 +410,12 @@ func (field *fieldMetadata) parseTag() {
 		field.defaultVal = defaultStr
 	}
 
+	// parse format tag
+	field.format = tagInfo.Get(tagFormat)
+
+	// parse prefix tag
+	field.mapPrefix = tagInfo.Get(tagPrefix)
+
 	// parse preprocessor tag
 	field.preprocessor = strings.Split(tagInfo.Get(tagPre), split)
 
@@ -244,6 +423,7 @@ func (field *fieldMetadata) parseTag() {
 	bindTag := tagInfo.Get(tagBind)
 	bindTags := strings.Split(bindTag, split)
 	isSourceSet := false
+	isNamed := false
 	for _, value := range bindTags {
 		value = strings.TrimSpace(value)
 		if value == "" {
@@ -252,6 +432,9 @@ func (field *fieldMetadata) parseTag() {
 
 		if source, ok := sourceMap[value]; ok {
 			field.source |= source
+			if source != auto {
+				field.sourceList = append(field.sourceList, source)
+			}
 			isSourceSet = true
 		} else {
 			switch value {
@@ -260,10 +443,21 @@ func (field *fieldMetadata) parseTag() {
 				isSourceSet = true
 			case bindRequired, bindReq:
 				field.isRequired = true
+			case bindOrdered:
+				field.isOrdered = true
+			case bindMerge:
+				field.isMerge = true
+			case bindUnique:
+				field.isUnique = true
+			case bindNoCase:
+				field.isNoCase = true
+			case bindNotNull:
+				field.isNotNull = true
 			default:
 				field.fieldJsonName = strings.TrimSuffix(field.fieldJsonName, field.fieldName)
 				field.fieldJsonName = field.fieldJsonName + value
 				field.fieldName = value
+				isNamed = true
 			}
 		}
 	}
@@ -271,17 +465,116 @@ func (field *fieldMetadata) parseTag() {
 	if !isSourceSet {
 		field.source |= auto
 	}
+
+	// parse query, header, form, path, cookie tags
+	for _, source := range sourceOrder {
+		if source == json || source == xml {
+			continue
+		}
+		name := strings.TrimSpace(tagInfo.Get(sourceNames[source]))
+		if name == "" {
+			continue
+		}
+		if field.sourceFieldNames == nil {
+			field.sourceFieldNames = make(map[int]string)
+		}
+		field.sourceFieldNames[source] = name
+	}
+
+	jsonName := ""
+	if b.jsonTag {
+		// parse json tag, 与 encoding/json 相同，只使用逗号前的名字，omitempty 等选项忽略
+		jsonName = strings.TrimSpace(strings.Split(tagInfo.Get(bindJson), split)[0])
+		// bind tag 中指定了名字时使用 bind tag 中的名字，json:"-" 仍然生效
+		if isNamed && jsonName != bindIgnore {
+			jsonName = ""
+		}
+	}
+
+	// 没有指定名字时按 naming strategy 生成
+	if !isNamed {
+		for source, strategy := range b.naming {
+			if source == json {
+				if jsonName == "" && !field.fieldType.Anonymous {
+					jsonName = strategy(field.fieldName)
+				}
+			} else if _, ok := field.sourceFieldNames[source]; !ok {
+				if field.sourceFieldNames == nil {
+					field.sourceFieldNames = make(map[int]string)
+				}
+				field.sourceFieldNames[source] = strategy(field.fieldName)
+			}
+		}
+	}
+
+	switch jsonName {
+	case "":
+	case bindIgnore:
+		// 不从 json 中获取，没有其他来源时忽略这个字段
+		field.source &^= json
+		field.sourceList = removeSource(field.sourceList, json)
+		if field.source == 0 {
+			field.isIgnored = true
+		}
+	default:
+		field.fieldJsonName = strings.TrimSuffix(field.fieldJsonName, field.fieldName) + jsonName
+	}
+}
+
+func removeSource(sources []int, source int) []int {
+	kept := sources[:0]
+	for _, s := range sources {
+		if s != source {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// joinPath 拼接父字段的路径和字段的名字
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// valuePath 返回错误中使用的名字：json 和 xml 为字段的路径，其他来源指定了名字时为其中查找的名字
+func (field *fieldMetadata) valuePath() string {
+	source := sourceMap[field.valueSource]
+	switch {
+	case source == xml:
+		return field.fieldXmlName
+	case source == json:
+	case field.sourceFieldNames[source] != "":
+		return field.sourceFieldNames[source]
+	}
+	return field.fieldJsonName
+}
+
+// nameOf 返回从 source 中获取值时使用的名字
+func (field *fieldMetadata) nameOf(source int) string {
+	if name, ok := field.sourceFieldNames[source]; ok {
+		return name
+	}
+	return field.fieldName
 }
 
 func ParseStruct(structType interface{}) *StructMetadata {
+	return defaultBinder.ParseStruct(structType)
+}
+
+// ParseStruct parses a struct (or a pointer to it) with the options of b, the
+// result can be passed to BindWithStructMeta.
+func (b *Binder) ParseStruct(structType interface{}) *StructMetadata {
 	typ := reflect.TypeOf(structType)
 	if typ.Kind() == reflect.Ptr {
 		typ = typ.Elem()
 	}
-	return parseStruct(&typ, "")
+	return b.parseStruct(&typ, "", "")
 }
 
-func parseStruct(structType *reflect.Type, parentFieldJsonName string) *StructMetadata {
+func (b *Binder) parseStruct(structType *reflect.Type, parentFieldJsonName, parentFieldXmlName string) *StructMetadata {
 	t := *structType
 	if t == fileType {
 		return nil
@@ -311,11 +604,18 @@ func parseStruct(structType *reflect.Type, parentFieldJsonName string) *StructMe
 
 		fieldMetaList[i] = fieldMeta
 
-		fieldMeta.parseTag()
+		fieldMeta.parseTag(b)
 		if fieldMeta.isIgnored {
 			continue
 		}
 
+		// 没有指定名字的匿名字段与父字段在 xml 中是同一层
+		if field.Anonymous && fieldMeta.fieldName == field.Name {
+			fieldMeta.fieldXmlName = parentFieldXmlName
+		} else {
+			fieldMeta.fieldXmlName = joinPath(parentFieldXmlName, fieldMeta.nameOf(xml))
+		}
+
 		// 最原始的类型，如 *struct
 		fieldType := field.Type
 		fieldMeta.originalType = field.Type
@@ -327,20 +627,28 @@ func parseStruct(structType *reflect.Type, parentFieldJsonName string) *StructMe
 		}
 		fieldMeta.elemType = fieldType
 
+		// 实现了 Unmarshaler 的类型作为一个整体转换
+		whole := isUnmarshaler(fieldType)
+
 		// 如果 field 是 struct
-		if fieldType.Kind() == reflect.Struct {
+		if fieldType.Kind() == reflect.Struct && !whole {
 			fieldMeta.isStruct = true
-			fieldMeta.structMeta = parseStruct(&fieldType, fieldMeta.fieldJsonName)
+			fieldMeta.structMeta = b.parseStruct(&fieldType, fieldMeta.fieldJsonName, fieldMeta.fieldXmlName)
 			if fieldType == fileType {
 				fieldMeta.isFile = true
 			}
-		} else if fieldType.Kind() == reflect.Slice {
+		} else if fieldType.Kind() == reflect.Slice && !whole {
 			fieldMeta.isSlice = true
-			fieldMeta.sliceMeta = parseSlice(&fieldType, fieldMeta.fieldJsonName)
+			fieldMeta.sliceMeta = b.parseSlice(&fieldType, fieldMeta.fieldJsonName, fieldMeta.fieldXmlName)
 			if fieldMeta.sliceMeta.elemType == fileType {
 				fieldMeta.isFile = true
 			}
+		} else if fieldType.Kind() == reflect.Map && !whole {
+			fieldMeta.isMap = true
+			fieldMeta.mapMeta = b.parseMap(&fieldType, fieldMeta.fieldJsonName, fieldMeta.fieldXmlName)
 		}
+
+		fieldMeta.parseValidateTag()
 	}
 
 	return &StructMetadata{
@@ -351,11 +659,12 @@ func parseStruct(structType *reflect.Type, parentFieldJsonName string) *StructMe
 	}
 }
 
-func parseSlice(sliceType *reflect.Type, parentFieldJsonName string) *sliceMetadata {
+func (b *Binder) parseSlice(sliceType *reflect.Type, parentFieldJsonName, parentFieldXmlName string) *sliceMetadata {
 	t := *sliceType
 	sliceMeta := &sliceMetadata{
 		sliceType:     t,
 		fieldJsonName: parentFieldJsonName,
+		fieldXmlName:  parentFieldXmlName,
 	}
 	// 从[]*struct 转为 *struct
 	sliceElementType := t.Elem()
@@ -368,10 +677,39 @@ func parseSlice(sliceType *reflect.Type, parentFieldJsonName string) *sliceMetad
 	}
 	sliceMeta.elemType = sliceElementType
 
-	sliceMeta.isStruct = sliceElementType.Kind() == reflect.Struct
+	sliceMeta.isStruct = sliceElementType.Kind() == reflect.Struct && !isUnmarshaler(sliceElementType)
 	if sliceMeta.isStruct {
-		sliceMeta.structMeta = parseStruct(&sliceElementType, parentFieldJsonName+".#")
+		sliceMeta.structMeta = b.parseStruct(&sliceElementType, parentFieldJsonName+".#", parentFieldXmlName+".#")
 	}
 
 	return sliceMeta
 }
+
+func (b *Binder) parseMap(mapType *reflect.Type, parentFieldJsonName, parentFieldXmlName string) *mapMetadata {
+	t := *mapType
+	mapMeta := &mapMetadata{
+		mapType:       t,
+		keyType:       t.Key(),
+		fieldJsonName: parentFieldJsonName,
+		fieldXmlName:  parentFieldXmlName,
+	}
+
+	// 从 map[string][]*T 变为 T
+	elemType := t.Elem()
+	if elemType.Kind() == reflect.Slice && !isUnmarshaler(elemType) {
+		mapMeta.isSlice = true
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+		mapMeta.isPtr = true
+	}
+	mapMeta.elemType = elemType
+
+	mapMeta.isStruct = !mapMeta.isSlice && elemType.Kind() == reflect.Struct && !isUnmarshaler(elemType)
+	if mapMeta.isStruct {
+		mapMeta.structMeta = b.parseStruct(&elemType, parentFieldJsonName+".#", parentFieldXmlName+".#")
+	}
+
+	return mapMeta
+}
@@ -1,26 +1,28 @@
 package binding
 
 import (
-	"errors"
 	"strings"
 )
 
 type Processor func(origin string) ([]string, error)
 
-var processorMap = map[string]Processor{
+// defaultProcessors 每个 Binder 自带的预处理器
+var defaultProcessors = map[string]Processor{
 	"split": func(origin string) ([]string, error) {
 		return strings.Split(origin, ","), nil
 	},
-	"__testErr": func(origin string) ([]string, error) {
-		return []string{}, errors.New("__testErr")
-	},
 }
 
-func RegisterPreprocessor(name string, processor Processor) {
-	processorMap[name] = processor
+// RegisterPreprocessor registers a preprocessor that can be used in the pre tag.
+func (b *Binder) RegisterPreprocessor(name string, processor Processor) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.processorMap[name] = processor
 }
 
-func getPreprocessor(name string) (Processor, bool) {
-	p, ok := processorMap[name]
+func (b *Binder) getPreprocessor(name string) (Processor, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	p, ok := b.processorMap[name]
 	return p, ok
 }
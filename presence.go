@@ -0,0 +1,93 @@
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Presence lists the fields a request provided, keyed by their path such as
+// Peoples.1.Name. Fields filled by a default value are not listed.
+type Presence map[string]FieldPresence
+
+// FieldPresence tells where a provided field came from.
+type FieldPresence struct {
+	// Source 获取到值的来源，如 query, json；合并多个来源时用英文逗号分隔
+	Source string
+
+	// Null json 中的值为 null
+	Null bool
+}
+
+// Has reports whether the field at path, or any field nested in it, was
+// provided, e.g. Has("Owner") is true when Owner.Name was sent.
+func (p Presence) Has(path string) bool {
+	if _, ok := p[path]; ok {
+		return true
+	}
+	for key := range p {
+		if strings.HasPrefix(key, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// BindPresence binds like Bind and also reports which fields the request
+// provided, to tell "not sent" from "sent as zero" in PATCH handlers.
+func (b *Binder) BindPresence(r Request, recvPtr interface{}) (Presence, error) {
+	recvType := reflect.TypeOf(recvPtr)
+	if recvType.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("A pointer is required but [%v] provided", recvType)
+	}
+	recvType = recvType.Elem()
+	if recvType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("A struct is required but [%v] provided", recvType)
+	}
+
+	sm, err := b.bind(r, recvPtr, b.getStructMeta(recvType))
+	if sm == nil {
+		return nil, err
+	}
+	presence := Presence{}
+	collectPresence(sm, presence)
+	return presence, err
+}
+
+func BindPresence(r Request, recvPtr interface{}) (Presence, error) {
+	return defaultBinder.BindPresence(r, recvPtr)
+}
+
+func collectPresence(structMeta *StructMetadata, presence Presence) {
+	for _, field := range structMeta.FieldList {
+		if field.isIgnored || !field.isExported {
+			continue
+		}
+		switch {
+		case field.isFile:
+			if field.hasValue {
+				presence[field.fieldJsonName] = FieldPresence{Source: bindForm}
+			}
+		case field.isNull:
+			presence[field.fieldJsonName] = FieldPresence{Source: bindJson, Null: true}
+		case field.isStruct:
+			collectPresence(field.structMeta, presence)
+		case field.isSlice && field.sliceMeta.isStruct:
+			if field.hasValue {
+				presence[field.fieldJsonName] = FieldPresence{Source: field.valueSource}
+			}
+			for _, sd := range field.sliceMeta.structData {
+				collectPresence(sd, presence)
+			}
+		case field.isMap:
+			if field.hasValue {
+				presence[field.fieldJsonName] = FieldPresence{Source: field.valueSource}
+			}
+			for _, sd := range field.mapMeta.structData {
+				collectPresence(sd, presence)
+			}
+		case field.valueSource != "" && field.valueSource != tagDefault:
+			presence[field.fieldJsonName] = FieldPresence{Source: field.valueSource, Null: field.isNull}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package binding
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindPresence(t *testing.T) {
+	type People struct {
+		Name string `bind:"name,json"`
+		Age  int    `bind:"age,json"`
+	}
+	type Recv struct {
+		Name    *string   `bind:"name,json"`
+		Age     int       `bind:"age,json"`
+		Nick    string    `bind:"nick,json"`
+		Limit   int       `bind:"limit,query"`
+		Page    int       `bind:"page,query" default:"1"`
+		Owner   People    `bind:"owner"`
+		Manager People    `bind:"manager"`
+		Peoples []*People `bind:"peoples"`
+		Ignored string    `bind:"-"`
+	}
+	req, _ := http.NewRequest("PATCH", "http://localhost:8080/?limit=0",
+		strings.NewReader(`{"name":null,"age":0,"owner":{"name":"o"},"peoples":[{"age":1}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	recv := new(Recv)
+	presence, err := NewBinder().BindPresence(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, Presence{
+		"name":          {Source: "json", Null: true},
+		"age":           {Source: "json"},
+		"limit":         {Source: "query"},
+		"owner.name":    {Source: "json"},
+		"peoples":       {Source: "json"},
+		"peoples.0.age": {Source: "json"},
+	}, presence)
+	assert.True(t, presence.Has("owner"))
+	assert.False(t, presence.Has("manager"))
+	assert.False(t, presence.Has("nick"))
+	assert.False(t, presence.Has("page"))
+	assert.Equal(t, 1, recv.Page)
+
+	_, err = BindPresence(WrapHTTPRequest(req), Recv{})
+	assert.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 const (
@@ -23,15 +24,58 @@ type Request interface {
 	GetBody() ([]byte, error)
 }
 
-func WrapHTTPRequest(req *http.Request) Request {
+// PathParamFunc returns the value of the path parameter key of req.
+type PathParamFunc func(req *http.Request, key string) (string, bool)
+
+// WrapOption configures the Request returned by WrapHTTPRequest.
+type WrapOption func(r *httpRequest)
+
+// WithPathParamFunc makes the wrapped request look up path parameters with f.
+// A nil f keeps the default lookup.
+func WithPathParamFunc(f PathParamFunc) WrapOption {
+	return func(r *httpRequest) {
+		if f != nil {
+			r.pathParam = f
+		}
+	}
+}
+
+// WithPathVars is for routers that return all path parameters of a request
+// as a map, e.g. WithPathVars(mux.Vars) for gorilla/mux.
+func WithPathVars(vars func(req *http.Request) map[string]string) WrapOption {
+	return WithPathParamFunc(func(req *http.Request, key string) (string, bool) {
+		v, ok := vars(req)[key]
+		return v, ok
+	})
+}
+
+// WithPathParamGetter is for routers that return a single path parameter by
+// name and an empty string if it is missing, e.g. WithPathParamGetter(chi.URLParam).
+func WithPathParamGetter(get func(req *http.Request, key string) string) WrapOption {
+	return WithPathParamFunc(func(req *http.Request, key string) (string, bool) {
+		v := get(req, key)
+		return v, v != ""
+	})
+}
+
+// WrapHTTPRequest wraps req as a Request. Path parameters are read from
+// http.Request.PathValue (Go 1.22+) unless a WrapOption supplies another lookup.
+func WrapHTTPRequest(req *http.Request, opts ...WrapOption) Request {
 	req.ParseMultipartForm(defaultMaxMemory)
-	return &httpRequest{
-		Request: req,
+	r := &httpRequest{
+		Request:   req,
+		pathParam: pathValue,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
 type httpRequest struct {
 	*http.Request
+
+	pathParam PathParamFunc
 }
 
 func (r *httpRequest) GetMethod() string {
@@ -42,7 +86,7 @@ func (r *httpRequest) GetQuery() url.Values {
 }
 
 func (r *httpRequest) GetPathParam(key string) (string, bool) {
-	return "", false
+	return r.pathParam(r.Request, key)
 }
 
 func (r *httpRequest) GetContentType() string {
@@ -91,6 +135,17 @@ type request struct {
 	body         []byte
 	cookie       []*http.Cookie
 	formFile     map[string][]*multipart.FileHeader
+
+	// Content-Type 对应的 BodyDecoder 解码后的 body，没有解码器或者解码失败时为 nil
+	doc Document
+
+	// 解析后的 xml body，body 不是 xml 时为 nil
+	xml *xmlNode
+
+	// 小写的 key 到原始 key 的索引，不区分大小写时第一次使用才建立
+	queryKeys map[string][]string
+	formKeys  map[string][]string
+	jsonKeys  map[string][]string
 }
 
 func newRequest(r Request) (*request, error) {
@@ -109,16 +164,18 @@ func newRequest(r Request) (*request, error) {
 		return nil, err
 	}
 
+	contentType := r.GetContentType()
 	return &request{
 		header:       r.GetHeader(),
 		query:        r.GetQuery(),
 		getPathParam: r.GetPathParam,
 		method:       r.GetMethod(),
-		contentType:  r.GetContentType(),
+		contentType:  contentType,
 		postForm:     postForm,
 		body:         body,
 		cookie:       r.GetCookies(),
 		formFile:     formFile,
+		xml:          parseXML(contentType, body),
 	}, nil
 }
 
@@ -140,13 +197,14 @@ func (r request) GetHeader(key string) ([]string, bool) {
 	return v, ok
 }
 
-func (r request) GetCookies(key string) *http.Cookie {
+func (r request) GetCookies(key string) ([]string, bool) {
+	var v []string
 	for _, cookie := range r.cookie {
 		if cookie.Name == key {
-			return cookie
+			v = append(v, cookie.Value)
 		}
 	}
-	return nil
+	return v, len(v) != 0
 }
 
 func (r request) GetFormFile(key string) ([]*multipart.FileHeader, bool) {
@@ -162,3 +220,75 @@ func (r request) GetPostForm(key string) ([]string, bool) {
 func (r request) GetBody() []byte {
 	return r.body
 }
+
+// GetDocument 从解码后的 body 中获取 path 的值
+func (r request) GetDocument(path string) (DocumentValue, bool) {
+	if r.doc == nil {
+		return DocumentValue{}, false
+	}
+	return r.doc.Get(path)
+}
+
+// GetDocumentArray 获取解码后的 body 中 path 的数组的元素
+func (r request) GetDocumentArray(path string) ([]DocumentValue, bool) {
+	if r.doc == nil || !r.doc.Exists(path) {
+		return nil, false
+	}
+	return r.doc.Array(path), true
+}
+
+// GetXML 从解析后的 xml body 中获取 path 的值，重复的元素返回多个值
+func (r request) GetXML(path string) ([]string, bool) {
+	if r.xml == nil {
+		return nil, false
+	}
+	values, _ := r.xml.lookup(path)
+	return values, len(values) != 0
+}
+
+// FoldQueryKey 返回与 key 只有大小写不同的 query key
+func (r *request) FoldQueryKey(key string) []string {
+	if r.queryKeys == nil {
+		r.queryKeys = valuesKeyIndex(r.query)
+	}
+	return r.queryKeys[strings.ToLower(key)]
+}
+
+// FoldPostFormKey 返回与 key 只有大小写不同的 form key
+func (r *request) FoldPostFormKey(key string) []string {
+	if r.formKeys == nil {
+		r.formKeys = valuesKeyIndex(r.postForm)
+	}
+	return r.formKeys[strings.ToLower(key)]
+}
+
+// FoldJsonKey 返回与 key 只有大小写不同的 json 顶层 key
+func (r *request) FoldJsonKey(key string) []string {
+	if r.jsonKeys == nil {
+		r.jsonKeys = make(map[string][]string)
+		if r.doc != nil {
+			for _, k := range r.doc.Keys("") {
+				addKey(r.jsonKeys, k)
+			}
+		}
+	}
+	return r.jsonKeys[strings.ToLower(key)]
+}
+
+func valuesKeyIndex(values url.Values) map[string][]string {
+	index := make(map[string][]string, len(values))
+	for k := range values {
+		addKey(index, k)
+	}
+	return index
+}
+
+func addKey(index map[string][]string, key string) {
+	lower := strings.ToLower(key)
+	for _, k := range index[lower] {
+		if k == key {
+			return
+		}
+	}
+	index[lower] = append(index[lower], key)
+}
@@ -0,0 +1,12 @@
+//go:build go1.22
+// +build go1.22
+
+package binding
+
+import "net/http"
+
+// pathValue reads the wildcards matched by http.ServeMux.
+func pathValue(req *http.Request, key string) (string, bool) {
+	v := req.PathValue(key)
+	return v, v != ""
+}
@@ -0,0 +1,11 @@
+//go:build !go1.22
+// +build !go1.22
+
+package binding
+
+import "net/http"
+
+// pathValue is a no-op before Go 1.22, http.ServeMux has no path wildcards.
+func pathValue(req *http.Request, key string) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,31 @@
+//go:build go1.22
+// +build go1.22
+
+package binding
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathValue(t *testing.T) {
+	type Recv struct {
+		ID   int    `bind:"id,path,req"`
+		Name string `bind:"name,path"`
+	}
+	req, _ := http.NewRequest("GET", "http://localhost:8080/users/42", nil)
+	req.SetPathValue("id", "42")
+	recv := new(Recv)
+	err := Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, recv.ID)
+	assert.Equal(t, "", recv.Name)
+
+	// nil 时仍然使用 PathValue
+	recv = new(Recv)
+	err = Bind(WrapHTTPRequest(req, WithPathParamFunc(nil)), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, recv.ID)
+}
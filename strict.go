@@ -0,0 +1,245 @@
+package binding
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StrictConfig configures the strict mode of WithStrict.
+type StrictConfig struct {
+	// AllowSources 不检查的来源，可选 query, form, json
+	AllowSources []string
+
+	// AllowPrefixes 以这些前缀开头的 key 不检查，json 中为完整路径，如 meta.
+	AllowPrefixes []string
+
+	// Warn 不为 nil 时未知的 key 交给 Warn，而不是作为错误返回
+	Warn func(err *Error)
+}
+
+// strictChecker 检查请求中没有字段使用的 key
+type strictChecker struct {
+	sources  int
+	prefixes []string
+	warn     func(err *Error)
+}
+
+// strictSources strict mode 检查的来源
+var strictSources = []int{query, form, json}
+
+// WithStrict reports the query, form and JSON keys that no field uses as
+// FieldUnknownError, JSON keys with their full path such as Peoples.1.Nmae.
+func WithStrict(config StrictConfig) Option {
+	return func(b *Binder) {
+		c := &strictChecker{
+			sources:  query | form | json,
+			prefixes: config.AllowPrefixes,
+			warn:     config.Warn,
+		}
+		for _, name := range config.AllowSources {
+			source, ok := sourceMap[name]
+			if !ok || !containsSource(strictSources, source) {
+				panic("go-binding: strict mode can't allow source " + name)
+			}
+			c.sources &^= source
+		}
+		b.strict = c
+	}
+}
+
+// keySet 一组 key，fold 中为不区分大小写的 key 的小写形式
+type keySet struct {
+	exact map[string]struct{}
+	fold  map[string]struct{}
+
+	// map 字段使用的 key 前缀
+	prefixes []string
+}
+
+func newKeySet() *keySet {
+	return &keySet{exact: map[string]struct{}{}, fold: map[string]struct{}{}}
+}
+
+func (s *keySet) add(key string, noCase bool) {
+	if noCase {
+		s.fold[strings.ToLower(key)] = struct{}{}
+	} else {
+		s.exact[key] = struct{}{}
+	}
+}
+
+func (s *keySet) addPrefix(prefix string) {
+	s.prefixes = append(s.prefixes, prefix)
+}
+
+func (s *keySet) has(key string) bool {
+	if _, ok := s.exact[key]; ok {
+		return true
+	}
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	_, ok := s.fold[strings.ToLower(key)]
+	return ok
+}
+
+// knownKeys 结构体中所有字段使用的 key
+type knownKeys struct {
+	noCase bool
+
+	query *keySet
+	form  *keySet
+
+	// json 中取值的完整路径
+	jsonLeaves *keySet
+
+	// json 中 struct 以及 struct slice 的路径，需要继续检查下一层
+	jsonNodes *keySet
+}
+
+func (k *knownKeys) collect(structMeta *StructMetadata) {
+	for _, field := range structMeta.FieldList {
+		if field.isIgnored || !field.isExported {
+			continue
+		}
+		if hasTag(field.source, query) {
+			k.query.add(field.nameOf(query), k.noCase || field.isNoCase)
+		}
+		if hasTag(field.source, form) {
+			k.form.add(field.nameOf(form), k.noCase || field.isNoCase)
+		}
+
+		if field.isFile {
+			continue
+		}
+		if field.isMap {
+			k.collectMap(field)
+		} else if field.isStruct {
+			k.jsonNodes.add(field.fieldJsonName, k.noCase || field.isNoCase)
+			k.collect(field.structMeta)
+		} else if field.isSlice && field.sliceMeta.isStruct {
+			k.jsonNodes.add(field.fieldJsonName, k.noCase || field.isNoCase)
+			k.collect(field.sliceMeta.structMeta)
+			for _, sd := range field.sliceMeta.structData {
+				k.collect(sd)
+			}
+		} else if hasTag(field.source, json) {
+			k.jsonLeaves.add(field.fieldJsonName, k.noCase || field.isNoCase)
+		}
+	}
+}
+
+func (k *knownKeys) collectMap(field *fieldMetadata) {
+	for _, source := range []int{query, form} {
+		if !hasTag(field.source, source) {
+			continue
+		}
+		set := k.query
+		if source == form {
+			set = k.form
+		}
+		if field.mapPrefix != "" {
+			set.addPrefix(field.mapPrefix)
+		} else {
+			set.addPrefix(field.nameOf(source) + "[")
+		}
+	}
+
+	if !field.mapMeta.isStruct {
+		if hasTag(field.source, json) {
+			k.jsonLeaves.add(field.fieldJsonName, k.noCase || field.isNoCase)
+		}
+		return
+	}
+	k.jsonNodes.add(field.fieldJsonName, k.noCase || field.isNoCase)
+	for i, sd := range field.mapMeta.structData {
+		k.jsonNodes.add(field.fieldJsonName+"."+escapePathKey(field.mapMeta.keys[i]), k.noCase || field.isNoCase)
+		k.collect(sd)
+	}
+}
+
+// checkUnknown 把请求中没有字段使用的 key 加入 errs
+func (c *strictChecker) checkUnknown(r *request, structMeta *StructMetadata, noCase bool, errs BindErrors) BindErrors {
+	k := &knownKeys{
+		noCase:     noCase,
+		query:      newKeySet(),
+		form:       newKeySet(),
+		jsonLeaves: newKeySet(),
+		jsonNodes:  newKeySet(),
+	}
+	k.collect(structMeta)
+
+	report := func(source int, key string, value string) {
+		for _, prefix := range c.prefixes {
+			// json 中前缀 meta. 也包括 meta 本身
+			if strings.HasPrefix(key, prefix) || strings.HasPrefix(key+".", prefix) {
+				return
+			}
+		}
+		err := FieldUnknownError.with(key, sourceNames[source], value, nil)
+		if c.warn != nil {
+			c.warn(err)
+			return
+		}
+		errs = append(errs, err)
+	}
+
+	if hasTag(c.sources, query) {
+		for _, key := range sortedKeys(r.query) {
+			if !k.query.has(key) {
+				report(query, key, r.query.Get(key))
+			}
+		}
+	}
+	if hasTag(c.sources, form) {
+		for _, key := range sortedKeys(r.postForm) {
+			if !k.form.has(key) {
+				report(form, key, r.postForm.Get(key))
+			}
+		}
+	}
+	if hasTag(c.sources, json) {
+		if r.doc != nil {
+			k.walkDocument(r.doc, "", func(path string, v DocumentValue) {
+				report(json, path, v.Text)
+			})
+		}
+	}
+	return errs
+}
+
+// walkDocument 检查 body 中对象的每个 key，parent 为对象在 Document 中的路径，
+// unknown 接收没有字段使用的路径。路径中的 key 与字段的路径一样转义，如 items.a\.b
+func (k *knownKeys) walkDocument(doc Document, parent string, unknown func(path string, v DocumentValue)) {
+	for _, key := range doc.Keys(parent) {
+		path := joinPath(parent, escapePathKey(key))
+		v, _ := doc.Get(path)
+		switch {
+		case k.jsonLeaves.has(path):
+		case k.jsonNodes.has(path):
+			if v.Kind == ObjectValue {
+				k.walkDocument(doc, path, unknown)
+			} else if v.Kind == ArrayValue {
+				for i, elem := range doc.Array(path) {
+					if elem.Kind == ObjectValue {
+						k.walkDocument(doc, path+"."+strconv.Itoa(i), unknown)
+					}
+				}
+			}
+		default:
+			unknown(path, v)
+		}
+	}
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
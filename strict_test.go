@@ -0,0 +1,95 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrict(t *testing.T) {
+	type People struct {
+		Name string `bind:"name,json"`
+	}
+	type Recv struct {
+		Limit   int       `bind:"limit,query"`
+		Page    int       `bind:"page,query,form,nocase"`
+		Tags    []string  `bind:"tags,json"`
+		Peoples []*People `bind:"peoples"`
+		Owner   People    `bind:"owner"`
+		Ignored string    `bind:"-"`
+	}
+	body := `{"tags":["a"],"peoples":[{"name":"a"},{"nmae":"b"}],"owner":{"name":"o","age":1},"extra":{"x":1},"meta":{"trace":"t"}}`
+	newReq := func() Request {
+		req, _ := http.NewRequest("POST", "http://localhost:8080/?limt=10&limit=1&PAGE=2&utm_source=x&Ignored=1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return WrapHTTPRequest(req)
+	}
+
+	b := NewBinder(WithStrict(StrictConfig{AllowPrefixes: []string{"utm_", "meta."}}))
+	recv := new(Recv)
+	err := b.Bind(newReq(), recv)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, FieldUnknownError))
+	var fields, sources []string
+	for _, e := range err.(BindErrors) {
+		fields = append(fields, e.Field)
+		sources = append(sources, e.Source)
+	}
+	assert.Equal(t, []string{"Ignored", "limt", "peoples.1.nmae", "owner.age", "extra"}, fields)
+	assert.Equal(t, []string{"query", "query", "json", "json", "json"}, sources)
+	assert.Equal(t, "go-binding error: field=limt, cause=parameter is not used by any field", err.(BindErrors)[1].Error())
+
+	// 字段仍然绑定
+	assert.Equal(t, 1, recv.Limit)
+	assert.Equal(t, 2, recv.Page)
+	assert.Equal(t, "a", recv.Peoples[0].Name)
+	assert.Equal(t, "o", recv.Owner.Name)
+
+	b = NewBinder(WithStrict(StrictConfig{AllowSources: []string{"json"}, AllowPrefixes: []string{"utm_"}}))
+	err = b.Bind(newReq(), new(Recv))
+	assert.Equal(t, "go-binding error: field=Ignored, cause=parameter is not used by any field; go-binding error: field=limt, cause=parameter is not used by any field", err.Error())
+
+	var warnings []string
+	b = NewBinder(WithStrict(StrictConfig{AllowSources: []string{"query"}, Warn: func(err *Error) {
+		warnings = append(warnings, err.Field)
+	}}))
+	err = b.Bind(newReq(), new(Recv))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"peoples.1.nmae", "owner.age", "extra", "meta"}, warnings)
+
+	assert.Panics(t, func() {
+		NewBinder(WithStrict(StrictConfig{AllowSources: []string{"header"}}))
+	})
+}
+
+func TestStrictMap(t *testing.T) {
+	type Item struct {
+		Name string `bind:"name,json"`
+	}
+	type Recv struct {
+		Meta    map[string]string `bind:"meta,query"`
+		Filters map[string]string `bind:"query" prefix:"f_"`
+		Labels  map[string]string `bind:"labels,json"`
+		Items   map[string]*Item  `bind:"items,json"`
+	}
+	req, _ := http.NewRequest("POST", "http://localhost:8080/?meta[a]=1&f_b=2&other=3",
+		strings.NewReader(`{"labels":{"a":"x"},"items":{"a":{"name":"n","nmae":"m"},"b.c":{"name":"n","d":"m"}}}`))
+	req.Header.Set("Content-Type", "application/json")
+	recv := new(Recv)
+	presence, err := NewBinder(WithStrict(StrictConfig{})).BindPresence(WrapHTTPRequest(req), recv)
+	assert.Equal(t, "go-binding error: field=other, cause=parameter is not used by any field; "+
+		"go-binding error: field=items.a.nmae, cause=parameter is not used by any field; "+
+		`go-binding error: field=items.b\.c.d, cause=parameter is not used by any field`, err.Error())
+	// map 的 key 中的 . 与查找时一样转义
+	assert.Equal(t, Presence{
+		"meta":            {Source: "query"},
+		"Filters":         {Source: "query"},
+		"labels":          {Source: "json"},
+		"items":           {Source: "json"},
+		"items.a.name":    {Source: "json"},
+		`items.b\.c.name`: {Source: "json"},
+	}, presence)
+}
@@ -0,0 +1,20 @@
+module github.com/kiancchen/go-binding/tomlbody
+
+go 1.18
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/kiancchen/go-binding v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.7.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/tidwall/gjson v1.8.1 // indirect
+	github.com/tidwall/match v1.0.3 // indirect
+	github.com/tidwall/pretty v1.1.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/kiancchen/go-binding => ../
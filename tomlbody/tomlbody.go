@@ -0,0 +1,27 @@
+// Package tomlbody decodes TOML request bodies for go-binding, json fields
+// read them with the same paths as a JSON body. It is a separate module that
+// needs Go 1.18 or later.
+//
+//	b := binding.NewBinder(binding.WithBodyDecoders(tomlbody.Decoder, tomlbody.MediaTypes...))
+package tomlbody
+
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+	binding "github.com/kiancchen/go-binding"
+)
+
+// MediaTypes are the media types of TOML bodies.
+var MediaTypes = []string{"application/toml"}
+
+// Decoder decodes a TOML body.
+var Decoder binding.BodyDecoder = binding.BodyDecoderFunc(decode)
+
+func decode(_ string, body []byte) (binding.Document, error) {
+	var tree map[string]interface{}
+	if _, err := toml.NewDecoder(bytes.NewReader(body)).Decode(&tree); err != nil {
+		return nil, err
+	}
+	return binding.NewTreeDocument(tree), nil
+}
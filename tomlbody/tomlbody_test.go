@@ -0,0 +1,61 @@
+package tomlbody
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	binding "github.com/kiancchen/go-binding"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBodyRequest(contentType, body string) binding.Request {
+	req, _ := http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return binding.WrapHTTPRequest(req)
+}
+
+func TestTOMLBody(t *testing.T) {
+	type Req struct {
+		Name    string    `bind:"name,json"`
+		Created time.Time `bind:"created,json"`
+		Owner   struct {
+			Email string `bind:"email,json,required"`
+		} `bind:"owner"`
+		Servers []*struct {
+			Host string `bind:"host,json,required"`
+			Port int    `bind:"port,json" default:"8080"`
+		} `bind:"servers"`
+	}
+	body := `
+name = "app"
+created = 2021-08-04T10:00:00Z
+
+[owner]
+email = "a@b.c"
+
+[[servers]]
+host = "h1"
+
+[[servers]]
+host = "h2"
+port = 82
+`
+	recv := new(Req)
+	b := binding.NewBinder(binding.WithBodyDecoders(Decoder, MediaTypes...))
+	err := b.Bind(newBodyRequest("application/toml", body), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "app", recv.Name)
+	assert.Equal(t, time.Date(2021, 8, 4, 10, 0, 0, 0, time.UTC), recv.Created)
+	// 表以及表数组
+	assert.Equal(t, "a@b.c", recv.Owner.Email)
+	assert.Equal(t, 2, len(recv.Servers))
+	assert.Equal(t, "h1", recv.Servers[0].Host)
+	assert.Equal(t, 8080, recv.Servers[0].Port)
+	assert.Equal(t, "h2", recv.Servers[1].Host)
+	assert.Equal(t, 82, recv.Servers[1].Port)
+
+	_, err = Decoder.Decode("application/toml", []byte("name = "))
+	assert.Error(t, err)
+}
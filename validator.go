@@ -0,0 +1,304 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// validate tag 的规则
+const (
+	ruleMin      = "min"
+	ruleMax      = "max"
+	ruleLen      = "len"
+	ruleRegexp   = "regexp"
+	ruleOneOf    = "oneof"
+	ruleEmail    = "email"
+	ruleURL      = "url"
+	ruleUUID     = "uuid"
+	ruleNonEmpty = "nonempty"
+
+	// dive 之后的规则作用于 slice 的每个元素
+	ruleDive = "dive"
+)
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validator 校验一个已经转换好的值，v 已解指针
+type validator func(v reflect.Value) error
+
+// parseValidateTag 解析 validate tag，规则写错时 panic
+func (field *fieldMetadata) parseValidateTag() {
+	tag, ok := field.tagInfo.Lookup(tagValidate)
+	if !ok || tag == "" {
+		return
+	}
+
+	t := field.elemType
+	for _, rule := range splitRules(tag) {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if rule == ruleDive {
+			if !field.isSlice {
+				panic(fmt.Sprintf("go-binding: validate rule dive requires a slice but field %s is %v", field.fieldJsonName, field.originalType))
+			}
+			t = field.sliceMeta.elemType
+			continue
+		}
+
+		v, err := newValidator(rule, t)
+		if err != nil {
+			panic(fmt.Sprintf("go-binding: invalid validate rule %q of field %s: %v", rule, field.fieldJsonName, err))
+		}
+		if t == field.elemType {
+			field.validators = append(field.validators, v)
+		} else {
+			field.elemValidators = append(field.elemValidators, v)
+		}
+	}
+}
+
+// splitRules 按英文逗号拆分规则，regexp 使用 tag 剩余的部分，正则表达式中可以有逗号，如 {1,3}
+func splitRules(tag string) []string {
+	var rules []string
+	for tag != "" {
+		if strings.HasPrefix(strings.TrimSpace(tag), ruleRegexp+"=") {
+			return append(rules, tag)
+		}
+		i := strings.Index(tag, split)
+		if i < 0 {
+			return append(rules, tag)
+		}
+		rules = append(rules, tag[:i])
+		tag = tag[i+1:]
+	}
+	return rules
+}
+
+func newValidator(rule string, t reflect.Type) (validator, error) {
+	name, param := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, param = rule[:i], rule[i+1:]
+	}
+
+	switch name {
+	case ruleMin, ruleMax:
+		return newRangeValidator(name == ruleMin, param, t)
+	case ruleLen:
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return nil, err
+		}
+		if !hasLen(t) {
+			return nil, fmt.Errorf("type %v has no length", t)
+		}
+		return func(v reflect.Value) error {
+			if length(v) != n {
+				return fmt.Errorf("length must be %d", n)
+			}
+			return nil
+		}, nil
+	case ruleRegexp:
+		re, err := regexp.Compile(param)
+		if err != nil {
+			return nil, err
+		}
+		return newStringValidator(t, func(s string) error {
+			if !re.MatchString(s) {
+				return fmt.Errorf("must match %s", param)
+			}
+			return nil
+		})
+	case ruleOneOf:
+		options := strings.Fields(param)
+		if len(options) == 0 {
+			return nil, errors.New("oneof requires at least one option")
+		}
+		return func(v reflect.Value) error {
+			s := fmt.Sprint(v.Interface())
+			for _, option := range options {
+				if s == option {
+					return nil
+				}
+			}
+			return fmt.Errorf("must be one of [%s]", param)
+		}, nil
+	case ruleEmail:
+		return newStringValidator(t, func(s string) error {
+			addr, err := mail.ParseAddress(s)
+			if err != nil || addr.Address != s {
+				return errors.New("must be a valid email")
+			}
+			return nil
+		})
+	case ruleURL:
+		return newStringValidator(t, func(s string) error {
+			u, err := url.ParseRequestURI(s)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				return errors.New("must be a valid url")
+			}
+			return nil
+		})
+	case ruleUUID:
+		return newStringValidator(t, func(s string) error {
+			if !uuidRegexp.MatchString(s) {
+				return errors.New("must be a valid uuid")
+			}
+			return nil
+		})
+	case ruleNonEmpty:
+		return func(v reflect.Value) error {
+			if v.IsZero() || (hasLen(v.Type()) && v.Len() == 0) {
+				return errors.New("must not be empty")
+			}
+			return nil
+		}, nil
+	}
+
+	return nil, errors.New("unknown rule")
+}
+
+// newRangeValidator min/max 对数字比较大小，对 string, slice, map 比较长度
+func newRangeValidator(isMin bool, param string, t reflect.Type) (validator, error) {
+	word := "at most"
+	if isMin {
+		word = "at least"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) error {
+			if (isMin && v.Int() < n) || (!isMin && v.Int() > n) {
+				return fmt.Errorf("must be %s %d", word, n)
+			}
+			return nil
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) error {
+			if (isMin && v.Uint() < n) || (!isMin && v.Uint() > n) {
+				return fmt.Errorf("must be %s %d", word, n)
+			}
+			return nil
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) error {
+			if (isMin && v.Float() < n) || (!isMin && v.Float() > n) {
+				return fmt.Errorf("must be %s %v", word, n)
+			}
+			return nil
+		}, nil
+	}
+
+	if !hasLen(t) {
+		return nil, fmt.Errorf("type %v is neither a number nor has a length", t)
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return nil, err
+	}
+	return func(v reflect.Value) error {
+		l := length(v)
+		if (isMin && l < n) || (!isMin && l > n) {
+			return fmt.Errorf("length must be %s %d", word, n)
+		}
+		return nil
+	}, nil
+}
+
+func newStringValidator(t reflect.Type, check func(s string) error) (validator, error) {
+	if t.Kind() != reflect.String {
+		return nil, fmt.Errorf("type %v is not a string", t)
+	}
+	return func(v reflect.Value) error {
+		return check(v.String())
+	}, nil
+}
+
+func hasLen(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+	return false
+}
+
+// length string 按字符计算长度
+func length(v reflect.Value) int {
+	if v.Kind() == reflect.String {
+		return utf8.RuneCountInString(v.String())
+	}
+	return v.Len()
+}
+
+// validateFields 对已经取到值的字段执行 validate tag 中的规则
+func validateFields(structMeta *StructMetadata, errs BindErrors) BindErrors {
+	for _, field := range structMeta.FieldList {
+		if field.isIgnored || !field.isExported || !field.hasValue || field.conversionErr != nil {
+			continue
+		}
+
+		v := *field.value
+		if err := runValidators(field.validators, v); err != nil {
+			errs = append(errs, FieldValidationError.with(field.valuePath(), field.valueSource, fmt.Sprint(v.Interface()), err))
+		} else if len(field.elemValidators) != 0 {
+			for i := 0; i < v.Len(); i++ {
+				elem := v.Index(i)
+				if elem.Kind() == reflect.Ptr {
+					if elem.IsNil() {
+						continue
+					}
+					elem = elem.Elem()
+				}
+				if err := runValidators(field.elemValidators, elem); err != nil {
+					path := field.valuePath() + "." + strconv.Itoa(i)
+					errs = append(errs, FieldValidationError.with(path, field.valueSource, fmt.Sprint(elem.Interface()), err))
+				}
+			}
+		}
+
+		if field.isFile {
+			continue
+		}
+		if field.isStruct {
+			errs = validateFields(field.structMeta, errs)
+		} else if field.isSlice {
+			for _, sd := range field.sliceMeta.structData {
+				errs = validateFields(sd, errs)
+			}
+		} else if field.isMap {
+			for _, sd := range field.mapMeta.structData {
+				errs = validateFields(sd, errs)
+			}
+		}
+	}
+	return errs
+}
+
+func runValidators(validators []validator, v reflect.Value) error {
+	for _, validate := range validators {
+		if err := validate(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package binding
+
+import (
+	"bytes"
+	xm "encoding/xml"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// xmlNode 解析后的 xml 元素
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*xmlNode
+}
+
+// parseXML 解析 body，Content-Type 不是 xml 或者 body 不合法时返回 nil
+func parseXML(contentType string, body []byte) *xmlNode {
+	if !isXMLContentType(contentType) {
+		return nil
+	}
+
+	decoder := xm.NewDecoder(bytes.NewReader(body))
+	var root *xmlNode
+	var stack []*xmlNode
+	var text [][]byte
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// 读完 body 时为 io.EOF，只有完整的根元素才有效
+			if root == nil || len(stack) != 0 {
+				return nil
+			}
+			return root
+		}
+		switch t := token.(type) {
+		case xm.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil
+				}
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+			text = append(text, nil)
+		case xm.CharData:
+			if len(text) != 0 {
+				text[len(text)-1] = append(text[len(text)-1], t...)
+			}
+		case xm.EndElement:
+			stack[len(stack)-1].text = strings.TrimSpace(string(text[len(text)-1]))
+			stack = stack[:len(stack)-1]
+			text = text[:len(text)-1]
+		}
+	}
+}
+
+func isXMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// elements 按路径获取元素，路径相对于根元素，如 Peoples.1.Name 为第二个 Peoples 元素中的 Name 元素。
+// 重复的元素都会返回，最后一段没有对应的元素时返回同名的属性
+func (n *xmlNode) lookup(path string) (values []string, elems []*xmlNode) {
+	elems = []*xmlNode{n}
+	keys := splitPath(path)
+	for i, key := range keys {
+		// xml 元素的名字不能以数字开头，数字为重复元素的下标
+		if idx, err := strconv.Atoi(key); err == nil {
+			if idx < 0 || idx >= len(elems) {
+				return nil, nil
+			}
+			elems = elems[idx : idx+1]
+			continue
+		}
+
+		var children []*xmlNode
+		for _, elem := range elems {
+			for _, child := range elem.children {
+				if child.name == key {
+					children = append(children, child)
+				}
+			}
+		}
+		if len(children) == 0 {
+			if i == len(keys)-1 && len(elems) != 0 {
+				if attr, ok := elems[0].attrs[key]; ok {
+					return []string{attr}, nil
+				}
+			}
+			return nil, nil
+		}
+		elems = children
+	}
+
+	for _, elem := range elems {
+		values = append(values, elem.text)
+	}
+	return values, elems
+}
@@ -0,0 +1,115 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXML(t *testing.T) {
+	type Part struct {
+		Sku   string `bind:"sku,xml"`
+		Count int    `bind:"count,xml,required"`
+	}
+	type Item struct {
+		Id    int     `bind:"id,xml"`
+		Name  string  `bind:"name"`
+		Parts []*Part `bind:"part"`
+	}
+	type Recv struct {
+		Id       int      `bind:"id"`
+		Currency string   `bind:"currency,xml"`
+		Tags     []string `bind:"tag,xml"`
+		Items    []Item   `bind:"item"`
+		Buyer    struct {
+			Name string `bind:"name,xml"`
+			Vip  bool   `bind:"vip,xml"`
+		} `bind:"buyer"`
+		Missing string `bind:"missing,xml,required"`
+		Json    string `bind:"id,json"`
+	}
+	body := `<?xml version="1.0"?>
+<order id="7" currency="EUR">
+	<tag>a</tag>
+	<tag>b</tag>
+	<item id="1">
+		<name>first</name>
+		<part><sku>s1</sku><count>1</count></part>
+		<part><sku>s2</sku></part>
+	</item>
+	<item id="2"><name>second</name></item>
+	<buyer vip="true"><name> Bob </name></buyer>
+</order>`
+	req, _ := http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	recv := new(Recv)
+	presence, err := NewBinder().BindPresence(WrapHTTPRequest(req), recv)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, FieldNotFound))
+	assert.Equal(t, "parameter required but not found: [item.0.part.1.count, missing]", err.Error())
+
+	assert.Equal(t, 7, recv.Id)
+	assert.Equal(t, "EUR", recv.Currency)
+	assert.Equal(t, []string{"a", "b"}, recv.Tags)
+	assert.Equal(t, 2, len(recv.Items))
+	assert.Equal(t, 1, recv.Items[0].Id)
+	assert.Equal(t, "first", recv.Items[0].Name)
+	assert.Equal(t, Part{"s1", 1}, *recv.Items[0].Parts[0])
+	assert.Equal(t, "s2", recv.Items[0].Parts[1].Sku)
+	assert.Equal(t, "second", recv.Items[1].Name)
+	assert.Nil(t, recv.Items[1].Parts)
+	assert.Equal(t, "Bob", recv.Buyer.Name)
+	assert.True(t, recv.Buyer.Vip)
+	assert.Equal(t, "", recv.Json)
+	assert.Equal(t, FieldPresence{Source: "xml"}, presence["item"])
+	assert.Equal(t, FieldPresence{Source: "xml"}, presence["item.1.name"])
+
+	// 不是 xml 时不解析
+	req, _ = http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/plain")
+	recv = new(Recv)
+	_ = Bind(WrapHTTPRequest(req), recv)
+	assert.Equal(t, "", recv.Currency)
+
+	req, _ = http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(`<order><tag>a</order>`))
+	req.Header.Set("Content-Type", "text/xml")
+	recv = new(Recv)
+	_ = Bind(WrapHTTPRequest(req), recv)
+	assert.Nil(t, recv.Tags)
+}
+
+func TestXMLNames(t *testing.T) {
+	type Recv struct {
+		SiteDomain string `json:"site_domain"`
+		UserName   string
+		Items      []struct {
+			ItemName string `json:"item_name"`
+		}
+	}
+	body := `<req><SiteDomain>a.com</SiteDomain><UserName>bob</UserName><Items><ItemName>x</ItemName></Items></req>`
+	req, _ := http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/xml")
+
+	// json tag 以及 json 的 naming strategy 不影响 xml 中的名字
+	recv := new(Recv)
+	err := NewBinder(WithNamingStrategy(CamelCase, "json")).Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "a.com", recv.SiteDomain)
+	assert.Equal(t, "bob", recv.UserName)
+	assert.Equal(t, 1, len(recv.Items))
+	assert.Equal(t, "x", recv.Items[0].ItemName)
+
+	body = `<req><site_domain>a.com</site_domain><user_name>bob</user_name><items><item_name>x</item_name></items></req>`
+	req, _ = http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/xml")
+	recv = new(Recv)
+	err = NewBinder(WithNamingStrategy(SnakeCase, "xml")).Bind(WrapHTTPRequest(req), recv)
+	assert.NoError(t, err)
+	assert.Equal(t, "a.com", recv.SiteDomain)
+	assert.Equal(t, "bob", recv.UserName)
+	assert.Equal(t, 1, len(recv.Items))
+	assert.Equal(t, "x", recv.Items[0].ItemName)
+}
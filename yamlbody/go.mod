@@ -0,0 +1,11 @@
+module github.com/kiancchen/go-binding/yamlbody
+
+go 1.13
+
+require (
+	github.com/kiancchen/go-binding v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/kiancchen/go-binding => ../
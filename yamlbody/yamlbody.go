@@ -0,0 +1,25 @@
+// Package yamlbody decodes YAML request bodies for go-binding, json fields
+// read them with the same paths as a JSON body. It is a separate module, so
+// the core module doesn't require the YAML library.
+//
+//	b := binding.NewBinder(binding.WithBodyDecoders(yamlbody.Decoder, yamlbody.MediaTypes...))
+package yamlbody
+
+import (
+	binding "github.com/kiancchen/go-binding"
+	"gopkg.in/yaml.v3"
+)
+
+// MediaTypes are the media types of YAML bodies.
+var MediaTypes = []string{"application/yaml", "application/x-yaml", "text/yaml"}
+
+// Decoder decodes a YAML body.
+var Decoder binding.BodyDecoder = binding.BodyDecoderFunc(decode)
+
+func decode(_ string, body []byte) (binding.Document, error) {
+	var tree interface{}
+	if err := yaml.Unmarshal(body, &tree); err != nil {
+		return nil, err
+	}
+	return binding.NewTreeDocument(tree), nil
+}
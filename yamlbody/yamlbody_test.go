@@ -0,0 +1,52 @@
+package yamlbody
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	binding "github.com/kiancchen/go-binding"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBodyRequest(contentType, body string) binding.Request {
+	req, _ := http.NewRequest("POST", "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return binding.WrapHTTPRequest(req)
+}
+
+func TestYAMLBody(t *testing.T) {
+	type Req struct {
+		Name   string            `bind:"name,json,required"`
+		Labels map[string]string `bind:"labels,json"`
+		Owner  struct {
+			Email string `bind:"email,json"`
+		} `bind:"owner"`
+	}
+	body := `
+name: app
+labels:
+  env: prod
+  1: one
+  true: on
+owner:
+  email: a@b.c
+`
+	b := binding.NewBinder(binding.WithBodyDecoders(Decoder, MediaTypes...))
+	for _, mediaType := range MediaTypes {
+		recv := new(Req)
+		err := b.Bind(newBodyRequest(mediaType, body), recv)
+		assert.NoError(t, err)
+		assert.Equal(t, "app", recv.Name)
+		// 非字符串的 key 转换为字符串
+		assert.Equal(t, map[string]string{"env": "prod", "1": "one", "true": "on"}, recv.Labels)
+		assert.Equal(t, "a@b.c", recv.Owner.Email)
+	}
+
+	_, err := Decoder.Decode("application/yaml", []byte("name: [unclosed"))
+	assert.Error(t, err)
+
+	// 没有注册时不解码 yaml
+	err = binding.NewBinder().Bind(newBodyRequest("application/yaml", body), new(Req))
+	assert.Equal(t, "parameter required but not found: [name]", err.Error())
+}